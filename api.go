@@ -4,21 +4,21 @@ import "encoding/json"
 
 // NewAPIRequest creates a new API request wrapped with the common
 // API fields.
-func newAPIRequest(cmd string, data any) (*wrapper, error) {
+func newAPIRequest(cmd string, data any) (*Wrapper, error) {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return nil, err
 	}
 
-	msg := wrapper{}
+	msg := Wrapper{}
 	msg.MSG.CMD = cmd
 	msg.MSG.Data = jsonData
 
 	return &msg, nil
 }
 
-// wrapper is a generic wrapper for all API requests and responses.
-type wrapper struct {
+// Wrapper is the envelope shared by all API requests and responses.
+type Wrapper struct {
 	MSG struct {
 		CMD  string          `json:"cmd"`
 		Data json.RawMessage `json:"data"`
@@ -11,7 +11,7 @@ func TestScanRequest(t *testing.T) {
 	data := scanRequest{AccountTopic: "reserve"}
 	dataBytes, err := json.Marshal(data)
 	assert.NoError(t, err, "failed to marshal JSON")
-	req := wrapper{}
+	req := Wrapper{}
 	req.MSG.CMD = "scan"
 	req.MSG.Data = dataBytes
 
@@ -24,7 +24,7 @@ func TestScanRequest(t *testing.T) {
 
 func TestScanResult(t *testing.T) {
 	jsonData := []byte(`{"msg":{"cmd":"scan","data":{"ip":"192.168.1.23","device":"1F:80:C5:32:32:36:72:4E","sku":"Hxxxx","bleVersionHard":"3.01.01","bleVersionSoft":"1.03.01","wifiVersionHard":"1.00.10","wifiVersionSoft":"1.02.03"}}}`)
-	var wrapper wrapper
+	var wrapper Wrapper
 	err := json.Unmarshal(jsonData, &wrapper)
 	assert.NoError(t, err, "failed to unmarshal wrapper")
 	assert.Equal(t, "scan", wrapper.MSG.CMD, "CMD mismatch")
@@ -45,7 +45,7 @@ func TestOnOffRequest(t *testing.T) {
 	data := onOffRequest{Value: 1}
 	dataBytes, err := json.Marshal(data)
 	assert.NoError(t, err, "failed to marshal JSON")
-	req := wrapper{}
+	req := Wrapper{}
 	req.MSG.CMD = "turn"
 	req.MSG.Data = dataBytes
 
@@ -60,7 +60,7 @@ func TestDevStatusRequest(t *testing.T) {
 	data := devStatusRequest{}
 	dataBytes, err := json.Marshal(data)
 	assert.NoError(t, err, "failed to marshal JSON")
-	req := wrapper{}
+	req := Wrapper{}
 	req.MSG.CMD = "devStatus"
 	req.MSG.Data = dataBytes
 	jsonData := []byte(`{"msg":{"cmd":"devStatus","data":{}}}`)
@@ -72,7 +72,7 @@ func TestDevStatusRequest(t *testing.T) {
 
 func TestDevStatusResponse(t *testing.T) {
 	jsonData := []byte(`{"msg":{"cmd":"devStatus","data":{"onOff":1,"brightness":100,"color":{"r":255,"g":0,"b":0},"colorTemInKelvin":7200}}}`)
-	var wrapper wrapper
+	var wrapper Wrapper
 	err := json.Unmarshal(jsonData, &wrapper)
 	assert.NoError(t, err, "failed to unmarshal wrapper")
 	assert.Equal(t, "devStatus", wrapper.MSG.CMD, "CMD mismatch")
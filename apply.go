@@ -0,0 +1,106 @@
+package govee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// StateDelta describes a change to some of a device's properties. Nil
+// fields are left as they are. At most one of Color and ColorKelvin may be
+// set.
+type StateDelta struct {
+	State       *State       `json:"state,omitempty"`
+	Brightness  *Brightness  `json:"brightness,omitempty"`
+	Color       *Color       `json:"color,omitempty"`
+	ColorKelvin *ColorKelvin `json:"colorKelvin,omitempty"`
+}
+
+// Names of the parts of a StateDelta, as reported in an ApplyResult.
+const (
+	PartState       = "state"
+	PartBrightness  = "brightness"
+	PartColor       = "color"
+	PartColorKelvin = "colorKelvin"
+)
+
+// ApplyResult reports what Device.Apply did with each part of a
+// StateDelta. Parts are named by the Part constants.
+type ApplyResult struct {
+	// Sent lists the parts whose commands were written to the network,
+	// in the order they were sent.
+	Sent []string
+	// Skipped lists the parts that were not sent, either because the
+	// device already reported that value or because the device is being
+	// turned off.
+	Skipped []string
+	// Failed holds the error for each part whose command failed.
+	Failed map[string]error
+}
+
+// Apply changes several properties of the device at once. Only the
+// commands needed to move from the last known state to the delta are
+// sent, and they are ordered so the light does not visibly pass through
+// intermediate states: color and brightness are set before the device is
+// turned on, and a device being turned off is only turned off, since
+// setting its color or brightness could switch it back on.
+//
+// Every needed command is attempted even if an earlier one fails. The
+// result reports which parts were sent, skipped and failed, and the
+// returned error joins the failures. A delta setting both Color and
+// ColorKelvin is rejected with ErrInvalidDelta before anything is sent.
+func (d *Device) Apply(ctx context.Context, delta StateDelta) (ApplyResult, error) {
+	if delta.Color != nil && delta.ColorKelvin != nil {
+		return ApplyResult{}, fmt.Errorf("%w: both color and color temperature set", ErrInvalidDelta)
+	}
+
+	// Without a status response the known state is meaningless, so
+	// every part is sent.
+	d.mu.RLock()
+	known, unknown := d.deviceState(), !d.reported
+	d.mu.RUnlock()
+	turningOff := delta.State != nil && *delta.State == 0
+
+	type step struct {
+		part    string
+		cmd     Command
+		changed bool
+	}
+	var steps []step
+	if turningOff {
+		steps = append(steps, step{PartState, TurnOffCommand(), unknown || known.State != 0})
+	}
+	if delta.Color != nil {
+		steps = append(steps, step{PartColor, ColorCommand(*delta.Color), unknown || known.Color != *delta.Color || known.ColorKelvin != 0})
+	}
+	if delta.ColorKelvin != nil {
+		steps = append(steps, step{PartColorKelvin, ColorKelvinCommand(*delta.ColorKelvin), unknown || known.ColorKelvin != *delta.ColorKelvin})
+	}
+	if delta.Brightness != nil {
+		steps = append(steps, step{PartBrightness, BrightnessCommand(*delta.Brightness), unknown || known.Brightness != *delta.Brightness})
+	}
+	if delta.State != nil && !turningOff {
+		steps = append(steps, step{PartState, TurnOnCommand(), unknown || known.State != 1})
+	}
+
+	var (
+		result ApplyResult
+		errs   []error
+	)
+	for _, s := range steps {
+		if !s.changed || (turningOff && s.part != PartState) {
+			result.Skipped = append(result.Skipped, s.part)
+			continue
+		}
+		if err := d.Do(ctx, s.cmd); err != nil {
+			if result.Failed == nil {
+				result.Failed = map[string]error{}
+			}
+			result.Failed[s.part] = err
+			errs = append(errs, fmt.Errorf("%s: %w", s.part, err))
+			continue
+		}
+		result.Sent = append(result.Sent, s.part)
+	}
+	return result, errors.Join(errs...)
+}
@@ -0,0 +1,125 @@
+package govee_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+	"github.com/swrm-io/go-vee/goveetest"
+)
+
+// sentCommands collects the commands sent within a short window.
+func sentCommands(transport *goveetest.Transport) []goveetest.Packet {
+	var packets []goveetest.Packet
+	for {
+		select {
+		case p := <-transport.Sent():
+			packets = append(packets, p)
+		case <-time.After(50 * time.Millisecond):
+			return packets
+		}
+	}
+}
+
+func TestApplyOrdersCommands(t *testing.T) {
+	transport, device := startWithTransport(t, govee.DeviceState{State: 0, Brightness: 10, Color: govee.NewColor(255, 0, 0)})
+
+	on := govee.State(1)
+	brightness := govee.NewBrightness(40)
+	warm := govee.NewColorKelvin(2700)
+	result, err := device.Apply(context.Background(), govee.StateDelta{State: &on, Brightness: &brightness, ColorKelvin: &warm})
+	require.NoError(t, err)
+	assert.Equal(t, []string{govee.PartColorKelvin, govee.PartBrightness, govee.PartState}, result.Sent)
+	assert.Empty(t, result.Skipped)
+	assert.Nil(t, result.Failed)
+
+	packets := sentCommands(transport)
+	require.Len(t, packets, 3)
+	assert.Equal(t, "colorwc", packets[0].Msg.MSG.CMD)
+	assert.Equal(t, "brightness", packets[1].Msg.MSG.CMD)
+	assert.Equal(t, "turn", packets[2].Msg.MSG.CMD)
+	var turn struct{ Value int }
+	sentValue(t, packets[2], &turn)
+	assert.Equal(t, 1, turn.Value)
+}
+
+func TestApplySkipsUnchanged(t *testing.T) {
+	transport, device := startWithTransport(t, govee.DeviceState{State: 1, Brightness: 40, Color: govee.NewColor(255, 0, 0)})
+
+	on := govee.State(1)
+	brightness := govee.NewBrightness(40)
+	red := govee.NewColor(255, 0, 0)
+	blue := govee.NewColor(0, 0, 255)
+	result, err := device.Apply(context.Background(), govee.StateDelta{State: &on, Brightness: &brightness, Color: &red})
+	require.NoError(t, err)
+	assert.Empty(t, result.Sent)
+	assert.Equal(t, []string{govee.PartColor, govee.PartBrightness, govee.PartState}, result.Skipped)
+	assert.Empty(t, sentCommands(transport))
+
+	result, err = device.Apply(context.Background(), govee.StateDelta{State: &on, Color: &blue})
+	require.NoError(t, err)
+	assert.Equal(t, []string{govee.PartColor}, result.Sent)
+	assert.Equal(t, []string{govee.PartState}, result.Skipped)
+	assert.Len(t, sentCommands(transport), 1)
+}
+
+func TestApplyTurningOff(t *testing.T) {
+	transport, device := startWithTransport(t, govee.DeviceState{State: 1, Brightness: 40})
+
+	off := govee.State(0)
+	brightness := govee.NewBrightness(80)
+	result, err := device.Apply(context.Background(), govee.StateDelta{State: &off, Brightness: &brightness})
+	require.NoError(t, err)
+	assert.Equal(t, []string{govee.PartState}, result.Sent)
+	assert.Equal(t, []string{govee.PartBrightness}, result.Skipped)
+
+	packets := sentCommands(transport)
+	require.Len(t, packets, 1)
+	var turn struct{ Value int }
+	sentValue(t, packets[0], &turn)
+	assert.Equal(t, 0, turn.Value)
+}
+
+func TestApplyUnreportedDevice(t *testing.T) {
+	// The devices have answered a scan but never reported their status,
+	// so their state is unknown and every part is sent.
+	c, transport := startHouse(t)
+	device, err := c.DeviceByID("kitchen-1")
+	require.NoError(t, err)
+
+	off := govee.State(0)
+	result, err := device.Apply(context.Background(), govee.StateDelta{State: &off})
+	require.NoError(t, err)
+	assert.Equal(t, []string{govee.PartState}, result.Sent)
+	assert.Empty(t, result.Skipped)
+
+	packets := sentCommands(transport)
+	require.Len(t, packets, 1)
+	assert.Equal(t, "turn", packets[0].Msg.MSG.CMD)
+}
+
+func TestApplyInvalidDelta(t *testing.T) {
+	transport, device := startWithTransport(t, govee.DeviceState{State: 1})
+
+	red := govee.NewColor(255, 0, 0)
+	warm := govee.NewColorKelvin(2700)
+	_, err := device.Apply(context.Background(), govee.StateDelta{Color: &red, ColorKelvin: &warm})
+	assert.ErrorIs(t, err, govee.ErrInvalidDelta)
+	assert.Empty(t, sentCommands(transport))
+}
+
+func TestApplyReportsFailures(t *testing.T) {
+	transport, device := startWithTransport(t, govee.DeviceState{State: 0, Brightness: 10})
+	require.NoError(t, transport.Close())
+
+	on := govee.State(1)
+	brightness := govee.NewBrightness(40)
+	result, err := device.Apply(context.Background(), govee.StateDelta{State: &on, Brightness: &brightness})
+	assert.ErrorIs(t, err, govee.ErrSendFailed)
+	assert.Empty(t, result.Sent)
+	assert.ErrorIs(t, result.Failed[govee.PartBrightness], govee.ErrSendFailed)
+	assert.ErrorIs(t, result.Failed[govee.PartState], govee.ErrSendFailed)
+}
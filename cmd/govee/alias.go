@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultAliasFile returns the aliases file named by $GOVEE_ALIASES, or
+// govee/aliases in the user's configuration directory.
+func defaultAliasFile() string {
+	if path := os.Getenv("GOVEE_ALIASES"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "govee", "aliases")
+}
+
+// loadAliases reads an aliases file. Each non-blank line that does not
+// start with # holds an alias and the IP address or device ID it stands
+// for, separated by whitespace. A missing file has no aliases.
+func loadAliases(path string) (map[string]string, error) {
+	aliases := map[string]string{}
+	if path == "" {
+		return aliases, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return aliases, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: want an alias and a device, got %q", path, n, line)
+		}
+		aliases[fields[0]] = fields[1]
+	}
+	return aliases, scanner.Err()
+}
+
+// alias returns the alias of the device with the given IP address or
+// device ID, or "" if it has none.
+func (c *cli) alias(ip, deviceID string) string {
+	for alias, target := range c.aliases {
+		if target == ip || target == deviceID {
+			return alias
+		}
+	}
+	return ""
+}
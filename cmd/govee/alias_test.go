@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAliases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aliases")
+	require.NoError(t, os.WriteFile(path, []byte("# rooms\n\nkitchen 1F:80:C5:32:32:36:72:4E\n  desk\t192.168.1.23  \n"), 0o600))
+
+	aliases, err := loadAliases(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"kitchen": "1F:80:C5:32:32:36:72:4E",
+		"desk":    "192.168.1.23",
+	}, aliases)
+
+	c := &cli{aliases: aliases}
+	assert.Equal(t, "desk", c.alias("192.168.1.23", ""))
+	assert.Equal(t, "kitchen", c.alias("10.0.0.1", "1F:80:C5:32:32:36:72:4E"))
+	assert.Equal(t, "", c.alias("10.0.0.1", "AA:BB"))
+
+	aliases, err = loadAliases(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, aliases)
+
+	require.NoError(t, os.WriteFile(path, []byte("kitchen\n"), 0o600))
+	_, err = loadAliases(path)
+	assert.ErrorContains(t, err, ":1:")
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	govee "github.com/swrm-io/go-vee"
+)
+
+// parseColor parses a color name such as "coral", a hex color such as
+// "#ff7f50" or "ff7f50", or "rgb(255, 127, 80)".
+func parseColor(s string) (govee.Color, error) {
+	if c, ok := colorNames[strings.ToLower(s)]; ok {
+		return c, nil
+	}
+
+	if inner, ok := strings.CutPrefix(strings.ToLower(strings.TrimSpace(s)), "rgb("); ok {
+		inner, ok = strings.CutSuffix(inner, ")")
+		parts := strings.Split(inner, ",")
+		if !ok || len(parts) != 3 {
+			return govee.Color{}, fmt.Errorf("invalid color %q: want rgb(r, g, b)", s)
+		}
+		var rgb [3]uint
+		for i, part := range parts {
+			v, err := strconv.ParseUint(strings.TrimSpace(part), 10, 8)
+			if err != nil {
+				return govee.Color{}, fmt.Errorf("invalid color %q: components must be 0 to 255", s)
+			}
+			rgb[i] = uint(v)
+		}
+		return govee.NewColor(rgb[0], rgb[1], rgb[2]), nil
+	}
+
+	if c, err := govee.ParseHex(s); err == nil {
+		return c, nil
+	}
+	return govee.Color{}, fmt.Errorf("invalid color %q: want a color name, #rrggbb or rgb(r, g, b)", s)
+}
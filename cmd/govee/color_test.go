@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := map[string]govee.Color{
+		"coral":            govee.Coral,
+		"CornflowerBlue":   govee.Cornflowerblue,
+		"#ff8800":          {R: 255, G: 136},
+		"ff8800":           {R: 255, G: 136},
+		"#f80":             {R: 255, G: 136},
+		"rgb(1, 2, 3)":     {R: 1, G: 2, B: 3},
+		"RGB(255,255,255)": govee.White,
+		" rgb( 0, 0, 9 ) ": {B: 9},
+	}
+	for input, want := range tests {
+		got, err := parseColor(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	for _, input := range []string{"", "notacolor", "#12345", "#gggggg", "rgb(1, 2)", "rgb(1, 2, 256)", "rgb(1, 2, 3"} {
+		_, err := parseColor(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestColorNames(t *testing.T) {
+	assert.Len(t, colorNames, 147)
+	assert.Equal(t, govee.Aliceblue, colorNames["aliceblue"])
+	for name, want := range colorNames {
+		got, err := parseColor(strings.ToUpper(name))
+		require.NoError(t, err, name)
+		assert.Equal(t, want, got, name)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	govee "github.com/swrm-io/go-vee"
+)
+
+// discover lists the devices that answer a scan.
+func discover(ctx context.Context, c *cli, _ []string) error {
+	devices, err := c.controller.Scan(ctx, c.wait)
+	if err != nil {
+		return err
+	}
+
+	results := make([]govee.DiscoveryResult, 0, len(devices))
+	for _, device := range devices {
+		s := device.Snapshot()
+		results = append(results, govee.DiscoveryResult{
+			IP:              s.IP,
+			DeviceID:        s.DeviceID,
+			SKU:             s.SKU,
+			BleVersionHard:  s.BleVersionHard,
+			BleVersionSoft:  s.BleVersionSoft,
+			WifiVersionHard: s.WifiVersionHard,
+			WifiVersionSoft: s.WifiVersionSoft,
+		})
+	}
+	if c.json {
+		return c.printJSON(results)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "IP\tDEVICE ID\tSKU\tALIAS\tBLE HW\tBLE SW\tWIFI HW\tWIFI SW")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.IP, r.DeviceID, r.SKU, c.alias(r.IP, r.DeviceID),
+			r.BleVersionHard, r.BleVersionSoft, r.WifiVersionHard, r.WifiVersionSoft)
+	}
+	return w.Flush()
+}
+
+// turnOn turns a device on.
+func turnOn(ctx context.Context, c *cli, args []string) error {
+	return c.do(ctx, args[0], govee.TurnOnCommand())
+}
+
+// turnOff turns a device off.
+func turnOff(ctx context.Context, c *cli, args []string) error {
+	return c.do(ctx, args[0], govee.TurnOffCommand())
+}
+
+// toggle asks a device for its state and switches it to the other one.
+func toggle(ctx context.Context, c *cli, args []string) error {
+	device, err := c.device(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	state, err := device.RequestStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if state.State == 1 {
+		return device.Do(ctx, govee.TurnOffCommand())
+	}
+	return device.Do(ctx, govee.TurnOnCommand())
+}
+
+// brightness sets the brightness of a device.
+func brightness(ctx context.Context, c *cli, args []string) error {
+	value, err := strconv.ParseUint(args[1], 10, 0)
+	if err != nil || value > 100 {
+		return fmt.Errorf("invalid brightness %q: want a number from 0 to 100", args[1])
+	}
+	return c.do(ctx, args[0], govee.BrightnessCommand(govee.NewBrightness(uint(value))))
+}
+
+// color sets the color of a device.
+func color(ctx context.Context, c *cli, args []string) error {
+	value, err := parseColor(args[1])
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, args[0], govee.ColorCommand(value))
+}
+
+// kelvin sets the color temperature of a device.
+func kelvin(ctx context.Context, c *cli, args []string) error {
+	value, err := strconv.ParseUint(strings.TrimSuffix(strings.ToUpper(args[1]), "K"), 10, 0)
+	if err != nil || value < 2000 || value > 9000 {
+		return fmt.Errorf("invalid color temperature %q: want a number from 2000 to 9000", args[1])
+	}
+	return c.do(ctx, args[0], govee.ColorKelvinCommand(govee.NewColorKelvin(uint(value))))
+}
+
+// status asks a device for its state and prints it.
+func status(ctx context.Context, c *cli, args []string) error {
+	device, err := c.device(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	state, err := device.RequestStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(state)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "IP:\t%s\n", state.IP)
+	fmt.Fprintf(w, "Device ID:\t%s\n", state.DeviceID)
+	fmt.Fprintf(w, "SKU:\t%s\n", state.SKU)
+	if alias := c.alias(state.IP, state.DeviceID); alias != "" {
+		fmt.Fprintf(w, "Alias:\t%s\n", alias)
+	}
+	fmt.Fprintf(w, "State:\t%s\n", state.State)
+	fmt.Fprintf(w, "Brightness:\t%s\n", state.Brightness)
+	if state.ColorKelvin != 0 {
+		fmt.Fprintf(w, "Color temperature:\t%s\n", state.ColorKelvin)
+	} else {
+		fmt.Fprintf(w, "Color:\t%s\n", state.Color)
+	}
+	return w.Flush()
+}
+
+// watch prints state changes of the given devices, or of all devices,
+// until ctx is canceled.
+func watch(ctx context.Context, c *cli, args []string) error {
+	targets := map[string]bool{}
+	for _, name := range args {
+		if target, ok := c.aliases[name]; ok {
+			name = target
+		}
+		targets[name] = true
+	}
+
+	events := c.controller.Subscribe(ctx, func(e govee.Event) bool {
+		if _, ok := e.(govee.StateChanged); !ok {
+			return false
+		}
+		d := e.EventDevice()
+		return len(targets) == 0 || targets[d.IP()] || targets[d.DeviceID()]
+	})
+	enc := json.NewEncoder(c.out)
+	for event := range events {
+		changed := event.(govee.StateChanged)
+		if c.json {
+			if err := enc.Encode(changed.New); err != nil {
+				return err
+			}
+			continue
+		}
+		name := c.alias(changed.New.IP, changed.New.DeviceID)
+		if name == "" {
+			name = changed.New.DeviceID
+		}
+		fmt.Fprintf(c.out, "%s %s %s\n", time.Now().Format(time.TimeOnly), name, describeChange(changed.Old, changed.New))
+	}
+	return nil
+}
+
+// describeChange lists the properties that differ between two states.
+func describeChange(old, new govee.DeviceState) string {
+	var parts []string
+	if old.State != new.State {
+		parts = append(parts, fmt.Sprintf("state %s -> %s", old.State, new.State))
+	}
+	if old.Brightness != new.Brightness {
+		parts = append(parts, fmt.Sprintf("brightness %s -> %s", old.Brightness, new.Brightness))
+	}
+	if old.Color != new.Color {
+		parts = append(parts, fmt.Sprintf("color %s -> %s", old.Color, new.Color))
+	}
+	if old.ColorKelvin != new.ColorKelvin {
+		parts = append(parts, fmt.Sprintf("color temperature %s -> %s", old.ColorKelvin, new.ColorKelvin))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printJSON writes v as indented JSON.
+func (c *cli) printJSON(v any) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
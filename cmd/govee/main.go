@@ -0,0 +1,248 @@
+// Command govee controls Govee lights on the local network.
+//
+// Usage:
+//
+//	govee <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	discover                     list the devices that answer a scan
+//	on <device>                  turn a device on
+//	off <device>                 turn a device off
+//	toggle <device>              turn a device on if it is off, off if it is on
+//	brightness <device> <0-100>  set the brightness
+//	color <device> <color>       set the color by name, hex or rgb(r, g, b)
+//	kelvin <device> <2000-9000>  set the color temperature
+//	status <device>              print the state of a device
+//	watch [device...]            print state changes as they happen
+//
+// A device is addressed by IP address, device ID or an alias from the
+// aliases file. Each line of the file holds an alias and the IP address or
+// device ID it stands for:
+//
+//	# ~/.config/govee/aliases
+//	kitchen  1F:80:C5:32:32:36:72:4E
+//	desk     192.168.1.23
+//
+// Run "govee <command> -h" for the flags of a command.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	govee "github.com/swrm-io/go-vee"
+)
+
+// errUsage is returned for invalid command lines, after the usage has
+// been printed.
+var errUsage = errors.New("usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	switch {
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "govee:", err)
+		os.Exit(1)
+	}
+}
+
+// command is a govee subcommand.
+type command struct {
+	name    string
+	args    string
+	summary string
+	// nargs is the number of arguments the command takes, or -1 for
+	// any number.
+	nargs int
+	// flags registers flags specific to the command.
+	flags func(*flag.FlagSet, *cli)
+	run   func(ctx context.Context, c *cli, args []string) error
+}
+
+var commands = []command{
+	{name: "discover", summary: "list the devices that answer a scan", nargs: 0, run: discover,
+		flags: func(fs *flag.FlagSet, c *cli) {
+			fs.DurationVar(&c.wait, "wait", 3*time.Second, "how long to wait for devices to answer")
+		}},
+	{name: "on", args: "<device>", summary: "turn a device on", nargs: 1, run: turnOn},
+	{name: "off", args: "<device>", summary: "turn a device off", nargs: 1, run: turnOff},
+	{name: "toggle", args: "<device>", summary: "turn a device on if it is off, off if it is on", nargs: 1, run: toggle},
+	{name: "brightness", args: "<device> <0-100>", summary: "set the brightness", nargs: 2, run: brightness},
+	{name: "color", args: "<device> <color>", summary: "set the color by name, hex or rgb(r, g, b)", nargs: 2, run: color},
+	{name: "kelvin", args: "<device> <2000-9000>", summary: "set the color temperature", nargs: 2, run: kelvin},
+	{name: "status", args: "<device>", summary: "print the state of a device", nargs: 1, run: status},
+	{name: "watch", args: "[device...]", summary: "print state changes as they happen", nargs: -1, run: watch,
+		flags: func(fs *flag.FlagSet, c *cli) {
+			fs.DurationVar(&c.poll, "poll", 10*time.Second, "how often to poll devices for their state")
+		}},
+}
+
+// cli holds the flags and the controller shared by all commands.
+type cli struct {
+	out        io.Writer
+	controller *govee.Controller
+	aliases    map[string]string
+
+	timeout   time.Duration
+	iface     string
+	aliasFile string
+	json      bool
+	verbose   bool
+	wait      time.Duration
+	poll      time.Duration
+}
+
+// run executes the command line args, writing output to stdout and
+// diagnostics to stderr. opts are passed to the controller after those
+// derived from flags.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer, opts ...govee.Option) error {
+	if len(args) == 0 {
+		usage(stderr)
+		return errUsage
+	}
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == args[0] {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		if args[0] != "help" && args[0] != "-h" && args[0] != "-help" {
+			fmt.Fprintf(stderr, "govee: unknown command %q\n", args[0])
+		}
+		usage(stderr)
+		return errUsage
+	}
+
+	c := &cli{out: stdout}
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: govee %s [flags] %s\n\n%s.\n\nFlags:\n", cmd.name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	fs.DurationVar(&c.timeout, "timeout", 5*time.Second, "how long to wait for a device")
+	fs.StringVar(&c.iface, "interface", "", "network interface to scan on")
+	fs.StringVar(&c.aliasFile, "aliases", defaultAliasFile(), "file of device aliases")
+	fs.BoolVar(&c.json, "json", false, "print JSON")
+	fs.BoolVar(&c.verbose, "v", false, "log controller activity to stderr")
+	if cmd.flags != nil {
+		cmd.flags(fs, c)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return errUsage
+	}
+	if cmd.nargs >= 0 && fs.NArg() != cmd.nargs {
+		fs.Usage()
+		return errUsage
+	}
+
+	aliases, err := loadAliases(c.aliasFile)
+	if err != nil {
+		return err
+	}
+	c.aliases = aliases
+
+	logger := slog.New(slog.DiscardHandler)
+	if c.verbose {
+		logger = slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	var controllerOpts []govee.Option
+	if c.iface != "" {
+		iface, err := net.InterfaceByName(c.iface)
+		if err != nil {
+			return err
+		}
+		controllerOpts = append(controllerOpts, govee.WithInterface(iface))
+	}
+	if c.poll > 0 {
+		controllerOpts = append(controllerOpts, govee.WithPollInterval(c.poll))
+	}
+	c.controller = govee.NewController(logger, append(controllerOpts, opts...)...)
+
+	started := make(chan error, 1)
+	go func() { started <- c.controller.Start() }()
+	defer c.controller.Shutdown()
+
+	if err := c.ready(ctx, started); err != nil {
+		return err
+	}
+	return cmd.run(ctx, c, fs.Args())
+}
+
+// ready waits for the controller to accept commands, or for Start to fail.
+func (c *cli) ready(ctx context.Context, started <-chan error) error {
+	ready := make(chan error, 1)
+	go func() { ready <- c.controller.Ready(ctx) }()
+	select {
+	case err := <-ready:
+		return err
+	case err := <-started:
+		if err == nil {
+			err = govee.ErrNotStarted
+		}
+		return fmt.Errorf("starting controller: %w", err)
+	}
+}
+
+// usage prints the list of commands.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: govee <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-30s %s\n", cmd.name+" "+cmd.args, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Devices are addressed by IP address, device ID or alias. Run "govee <command> -h" for flags.`)
+}
+
+// device resolves a device by alias, IP address or device ID. Devices
+// given by IP address are registered directly, so they can be controlled
+// even where multicast discovery does not work; devices given by ID are
+// waited for until the timeout.
+func (c *cli) device(ctx context.Context, name string) (*govee.Device, error) {
+	if target, ok := c.aliases[name]; ok {
+		name = target
+	}
+	if ip := net.ParseIP(name); ip != nil && ip.To4() != nil {
+		return c.controller.AddDevice(name, govee.DeviceOptions{})
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	device, err := c.controller.WaitForDevice(ctx, name)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("device %q not found within %s", name, c.timeout)
+	}
+	return device, err
+}
+
+// do resolves the named device and sends cmd to it.
+func (c *cli) do(ctx context.Context, name string, cmd govee.Command) error {
+	device, err := c.device(ctx, name)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return device.Do(ctx, cmd)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+	"github.com/swrm-io/go-vee/goveetest"
+)
+
+// lights answers the controller's requests on in-memory transports as a
+// set of devices would.
+type lights struct {
+	t *testing.T
+
+	mu       sync.Mutex
+	ids      map[string]string
+	states   map[string]govee.DeviceState
+	commands []string
+}
+
+// newLights serves devices keyed by IP address, with the given IDs, until
+// the test ends.
+func newLights(t *testing.T, ids map[string]string) *lights {
+	t.Setenv("GOVEE_ALIASES", filepath.Join(t.TempDir(), "aliases"))
+	return &lights{
+		t:      t,
+		ids:    ids,
+		states: map[string]govee.DeviceState{},
+	}
+}
+
+// serve returns a new transport answered by the devices until the test
+// ends.
+func (l *lights) serve() *goveetest.Transport {
+	transport := goveetest.NewTransport()
+	done := make(chan struct{})
+	l.t.Cleanup(func() { close(done) })
+	go func() {
+		for {
+			select {
+			case p := <-transport.Sent():
+				l.handle(transport, p)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return transport
+}
+
+// handle applies a sent packet and delivers any reply.
+func (l *lights) handle(transport *goveetest.Transport, p goveetest.Packet) {
+	ip, _, _ := net.SplitHostPort(p.Addr)
+	var data struct {
+		Value int `json:"value"`
+	}
+	_ = json.Unmarshal(p.Msg.MSG.Data, &data)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.commands = append(l.commands, p.Msg.MSG.CMD+" "+ip)
+	state := l.states[ip]
+	switch p.Msg.MSG.CMD {
+	case "scan":
+		for ip, id := range l.ids {
+			_ = transport.Announce(ip, id, "H6159")
+		}
+	case "turn":
+		state.State = govee.State(data.Value)
+	case "brightness":
+		state.Brightness = govee.Brightness(data.Value)
+	case "colorwc":
+		var color struct {
+			Color  govee.Color       `json:"color"`
+			Kelvin govee.ColorKelvin `json:"colorTemInKelvin"`
+		}
+		_ = json.Unmarshal(p.Msg.MSG.Data, &color)
+		state.Color, state.ColorKelvin = color.Color, color.Kelvin
+	case "devStatus":
+		_ = transport.DeliverStatus(ip, state)
+	}
+	l.states[ip] = state
+}
+
+// state returns the simulated state of the device at ip.
+func (l *lights) state(ip string) govee.DeviceState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.states[ip]
+}
+
+// run runs the command line against l and returns its output.
+func (l *lights) run(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return l.runContext(ctx, args...)
+}
+
+// runContext runs the command line against l until ctx is done.
+func (l *lights) runContext(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	err := run(ctx, args, &stdout, &stderr, govee.WithTransport(l.serve()))
+	return stdout.String(), err
+}
+
+func TestDiscover(t *testing.T) {
+	l := newLights(t, map[string]string{"10.0.0.5": "AA:BB", "10.0.0.6": "CC:DD"})
+
+	out, err := l.run(t, "discover", "-wait", "100ms")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "DEVICE ID")
+	assert.Contains(t, out, "AA:BB")
+	assert.Contains(t, out, "CC:DD")
+	assert.Contains(t, out, "1.0.10")
+
+	out, err = l.run(t, "discover", "-wait", "100ms", "-json")
+	require.NoError(t, err)
+	var results []govee.DiscoveryResult
+	require.NoError(t, json.Unmarshal([]byte(out), &results))
+	assert.Len(t, results, 2)
+}
+
+func TestControlCommands(t *testing.T) {
+	l := newLights(t, map[string]string{"10.0.0.5": "AA:BB"})
+
+	_, err := l.run(t, "on", "10.0.0.5")
+	require.NoError(t, err)
+	_, err = l.run(t, "brightness", "AA:BB", "40")
+	require.NoError(t, err)
+	_, err = l.run(t, "color", "10.0.0.5", "coral")
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return l.state("10.0.0.5") == govee.DeviceState{State: 1, Brightness: 40, Color: govee.Coral}
+	}, time.Second, time.Millisecond)
+
+	_, err = l.run(t, "kelvin", "10.0.0.5", "2700K")
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return l.state("10.0.0.5").ColorKelvin == 2700 }, time.Second, time.Millisecond)
+
+	_, err = l.run(t, "toggle", "10.0.0.5")
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return l.state("10.0.0.5").State == 0 }, time.Second, time.Millisecond)
+}
+
+func TestStatus(t *testing.T) {
+	l := newLights(t, map[string]string{"10.0.0.5": "AA:BB"})
+	require.NoError(t, os.WriteFile(os.Getenv("GOVEE_ALIASES"), []byte("# lights\ndesk 10.0.0.5\n"), 0o600))
+	_, err := l.run(t, "brightness", "desk", "70")
+	require.NoError(t, err)
+
+	out, err := l.run(t, "status", "desk")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Alias:")
+	assert.Contains(t, out, "desk")
+	assert.Contains(t, out, "70%")
+
+	out, err = l.run(t, "status", "-json", "AA:BB")
+	require.NoError(t, err)
+	var state govee.DeviceState
+	require.NoError(t, json.Unmarshal([]byte(out), &state))
+	assert.Equal(t, "10.0.0.5", state.IP)
+	assert.Equal(t, govee.Brightness(70), state.Brightness)
+}
+
+func TestUsageErrors(t *testing.T) {
+	l := newLights(t, nil)
+	for _, args := range [][]string{{}, {"dance"}, {"on"}, {"brightness", "10.0.0.5"}} {
+		_, err := l.run(t, args...)
+		assert.ErrorIs(t, err, errUsage, "%q", args)
+	}
+
+	_, err := l.run(t, "brightness", "10.0.0.5", "120")
+	assert.ErrorContains(t, err, "invalid brightness")
+	_, err = l.run(t, "on", "-timeout", "50ms", "EE:FF")
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestWatch(t *testing.T) {
+	l := newLights(t, map[string]string{"10.0.0.5": "AA:BB", "10.0.0.6": "CC:DD"})
+	l.states["10.0.0.5"] = govee.DeviceState{State: 1, Brightness: 30}
+	l.states["10.0.0.6"] = govee.DeviceState{State: 1, Brightness: 60}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	out, err := l.runContext(ctx, "watch", "-json", "-poll", "20ms", "AA:BB")
+	require.NoError(t, err)
+
+	var state govee.DeviceState
+	dec := json.NewDecoder(strings.NewReader(out))
+	require.NoError(t, dec.Decode(&state))
+	assert.Equal(t, "AA:BB", state.DeviceID)
+	assert.Equal(t, govee.Brightness(30), state.Brightness)
+	assert.NotContains(t, out, "CC:DD")
+}
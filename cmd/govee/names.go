@@ -0,0 +1,155 @@
+package main
+
+import govee "github.com/swrm-io/go-vee"
+
+// colorNames maps the CSS color keywords to the named colors of the govee
+// package.
+var colorNames = map[string]govee.Color{
+	"aliceblue":            govee.Aliceblue,
+	"antiquewhite":         govee.Antiquewhite,
+	"aqua":                 govee.Aqua,
+	"aquamarine":           govee.Aquamarine,
+	"azure":                govee.Azure,
+	"beige":                govee.Beige,
+	"bisque":               govee.Bisque,
+	"black":                govee.Black,
+	"blanchedalmond":       govee.Blanchedalmond,
+	"blue":                 govee.Blue,
+	"blueviolet":           govee.Blueviolet,
+	"brown":                govee.Brown,
+	"burlywood":            govee.Burlywood,
+	"cadetblue":            govee.Cadetblue,
+	"chartreuse":           govee.Chartreuse,
+	"chocolate":            govee.Chocolate,
+	"coral":                govee.Coral,
+	"cornflowerblue":       govee.Cornflowerblue,
+	"cornsilk":             govee.Cornsilk,
+	"crimson":              govee.Crimson,
+	"cyan":                 govee.Cyan,
+	"darkblue":             govee.Darkblue,
+	"darkcyan":             govee.Darkcyan,
+	"darkgoldenrod":        govee.Darkgoldenrod,
+	"darkgray":             govee.Darkgray,
+	"darkgreen":            govee.Darkgreen,
+	"darkgrey":             govee.Darkgrey,
+	"darkkhaki":            govee.Darkkhaki,
+	"darkmagenta":          govee.Darkmagenta,
+	"darkolivegreen":       govee.Darkolivegreen,
+	"darkorange":           govee.Darkorange,
+	"darkorchid":           govee.Darkorchid,
+	"darkred":              govee.Darkred,
+	"darksalmon":           govee.Darksalmon,
+	"darkseagreen":         govee.Darkseagreen,
+	"darkslateblue":        govee.Darkslateblue,
+	"darkslategray":        govee.Darkslategray,
+	"darkslategrey":        govee.Darkslategrey,
+	"darkturquoise":        govee.Darkturquoise,
+	"darkviolet":           govee.Darkviolet,
+	"deeppink":             govee.Deeppink,
+	"deepskyblue":          govee.Deepskyblue,
+	"dimgray":              govee.Dimgray,
+	"dimgrey":              govee.Dimgrey,
+	"dodgerblue":           govee.Dodgerblue,
+	"firebrick":            govee.Firebrick,
+	"floralwhite":          govee.Floralwhite,
+	"forestgreen":          govee.Forestgreen,
+	"fuchsia":              govee.Fuchsia,
+	"gainsboro":            govee.Gainsboro,
+	"ghostwhite":           govee.Ghostwhite,
+	"gold":                 govee.Gold,
+	"goldenrod":            govee.Goldenrod,
+	"gray":                 govee.Gray,
+	"green":                govee.Green,
+	"greenyellow":          govee.Greenyellow,
+	"grey":                 govee.Grey,
+	"honeydew":             govee.Honeydew,
+	"hotpink":              govee.Hotpink,
+	"indianred":            govee.Indianred,
+	"indigo":               govee.Indigo,
+	"ivory":                govee.Ivory,
+	"khaki":                govee.Khaki,
+	"lavender":             govee.Lavender,
+	"lavenderblush":        govee.Lavenderblush,
+	"lawngreen":            govee.Lawngreen,
+	"lemonchiffon":         govee.Lemonchiffon,
+	"lightblue":            govee.Lightblue,
+	"lightcoral":           govee.Lightcoral,
+	"lightcyan":            govee.Lightcyan,
+	"lightgoldenrodyellow": govee.Lightgoldenrodyellow,
+	"lightgray":            govee.Lightgray,
+	"lightgreen":           govee.Lightgreen,
+	"lightgrey":            govee.Lightgrey,
+	"lightpink":            govee.Lightpink,
+	"lightsalmon":          govee.Lightsalmon,
+	"lightseagreen":        govee.Lightseagreen,
+	"lightskyblue":         govee.Lightskyblue,
+	"lightslategray":       govee.Lightslategray,
+	"lightslategrey":       govee.Lightslategrey,
+	"lightsteelblue":       govee.Lightsteelblue,
+	"lightyellow":          govee.Lightyellow,
+	"lime":                 govee.Lime,
+	"limegreen":            govee.Limegreen,
+	"linen":                govee.Linen,
+	"magenta":              govee.Magenta,
+	"maroon":               govee.Maroon,
+	"mediumaquamarine":     govee.Mediumaquamarine,
+	"mediumblue":           govee.Mediumblue,
+	"mediumorchid":         govee.Mediumorchid,
+	"mediumpurple":         govee.Mediumpurple,
+	"mediumseagreen":       govee.Mediumseagreen,
+	"mediumslateblue":      govee.Mediumslateblue,
+	"mediumspringgreen":    govee.Mediumspringgreen,
+	"mediumturquoise":      govee.Mediumturquoise,
+	"mediumvioletred":      govee.Mediumvioletred,
+	"midnightblue":         govee.Midnightblue,
+	"mintcream":            govee.Mintcream,
+	"mistyrose":            govee.Mistyrose,
+	"moccasin":             govee.Moccasin,
+	"navajowhite":          govee.Navajowhite,
+	"navy":                 govee.Navy,
+	"oldlace":              govee.Oldlace,
+	"olive":                govee.Olive,
+	"olivedrab":            govee.Olivedrab,
+	"orange":               govee.Orange,
+	"orangered":            govee.Orangered,
+	"orchid":               govee.Orchid,
+	"palegoldenrod":        govee.Palegoldenrod,
+	"palegreen":            govee.Palegreen,
+	"paleturquoise":        govee.Paleturquoise,
+	"palevioletred":        govee.Palevioletred,
+	"papayawhip":           govee.Papayawhip,
+	"peachpuff":            govee.Peachpuff,
+	"peru":                 govee.Peru,
+	"pink":                 govee.Pink,
+	"plum":                 govee.Plum,
+	"powderblue":           govee.Powderblue,
+	"purple":               govee.Purple,
+	"red":                  govee.Red,
+	"rosybrown":            govee.Rosybrown,
+	"royalblue":            govee.Royalblue,
+	"saddlebrown":          govee.Saddlebrown,
+	"salmon":               govee.Salmon,
+	"sandybrown":           govee.Sandybrown,
+	"seagreen":             govee.Seagreen,
+	"seashell":             govee.Seashell,
+	"sienna":               govee.Sienna,
+	"silver":               govee.Silver,
+	"skyblue":              govee.Skyblue,
+	"slateblue":            govee.Slateblue,
+	"slategray":            govee.Slategray,
+	"slategrey":            govee.Slategrey,
+	"snow":                 govee.Snow,
+	"springgreen":          govee.Springgreen,
+	"steelblue":            govee.Steelblue,
+	"tan":                  govee.Tan,
+	"teal":                 govee.Teal,
+	"thistle":              govee.Thistle,
+	"tomato":               govee.Tomato,
+	"turquoise":            govee.Turquoise,
+	"violet":               govee.Violet,
+	"wheat":                govee.Wheat,
+	"white":                govee.White,
+	"whitesmoke":           govee.Whitesmoke,
+	"yellow":               govee.Yellow,
+	"yellowgreen":          govee.Yellowgreen,
+}
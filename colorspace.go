@@ -0,0 +1,184 @@
+package govee
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// HSV returns the hue in degrees [0, 360), and the saturation and value in
+// [0, 1]. Grays have a hue of 0.
+func (c Color) HSV() (h, s, v float64) {
+	r, g, b := c.unit()
+	maxC := max(r, g, b)
+	delta := maxC - min(r, g, b)
+	if maxC > 0 {
+		s = delta / maxC
+	}
+	return hue(r, g, b, maxC, delta), s, maxC
+}
+
+// FromHSV returns the color with hue h in degrees, and saturation s and
+// value v in [0, 1]. Hues outside [0, 360) wrap around; saturation and
+// value are clamped.
+func FromHSV(h, s, v float64) Color {
+	s, v = clampUnit(s), clampUnit(v)
+	chroma := v * s
+	return fromHueChroma(h, chroma, v-chroma)
+}
+
+// FromHSL returns the color with hue h in degrees, and saturation s and
+// lightness l in [0, 1]. Hues outside [0, 360) wrap around; saturation
+// and lightness are clamped.
+func FromHSL(h, s, l float64) Color {
+	s, l = clampUnit(s), clampUnit(l)
+	chroma := (1 - math.Abs(2*l-1)) * s
+	return fromHueChroma(h, chroma, l-chroma/2)
+}
+
+// ParseHex parses a hex color such as "#ff8800", "ff8800" or the short
+// form "#f80".
+func ParseHex(s string) (Color, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return Color{}, fmt.Errorf("%w: %q is not #rrggbb or #rgb", ErrInvalidColor, s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("%w: %q is not #rrggbb or #rgb", ErrInvalidColor, s)
+	}
+	return Color{R: uint(v >> 16), G: uint(v >> 8 & 0xff), B: uint(v & 0xff)}, nil
+}
+
+// Hex returns the color as "#rrggbb". Components above 255 are clamped.
+func (c Color) Hex() string {
+	c = NewColor(c.R, c.G, c.B)
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// KelvinToRGB returns the color of a black body at color temperature k,
+// for showing what a color temperature looks like. It uses Tanner
+// Helland's approximation of the Planckian locus, accurate enough for
+// display but not for colorimetry.
+func KelvinToRGB(k ColorKelvin) Color {
+	r, g, b := planckian(float64(k))
+	return Color{R: unitToChannel(r), G: unitToChannel(g), B: unitToChannel(b)}
+}
+
+// RGBToKelvin returns the color temperature, in the range Govee devices
+// accept, whose KelvinToRGB color is closest in warmth to c. It compares
+// the balance of blue to red only, so any color gets an answer; the
+// result is only meaningful for whites and near-whites.
+func RGBToKelvin(c Color) ColorKelvin {
+	c = NewColor(c.R, c.G, c.B)
+	if c.R == 0 {
+		return NewColorKelvin(math.MaxUint)
+	}
+	target := float64(c.B) / float64(c.R)
+
+	// The blue to red ratio of the locus increases with temperature, so
+	// binary search for it.
+	lo, hi := float64(NewColorKelvin(0)), float64(NewColorKelvin(math.MaxUint))
+	for hi-lo > 0.5 {
+		mid := (lo + hi) / 2
+		r, _, b := planckian(mid)
+		if b/r < target {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return NewColorKelvin(uint(math.Round((lo + hi) / 2)))
+}
+
+// planckian returns the color of a black body at kelvin, with channels in
+// [0, 1] and before rounding, so RGBToKelvin can search it smoothly.
+func planckian(kelvin float64) (r, g, b float64) {
+	t := math.Max(1000, math.Min(40000, kelvin)) / 100
+	if t <= 66 {
+		r = 255
+		g = 99.4708025861*math.Log(t) - 161.1195681661
+	} else {
+		r = 329.698727446 * math.Pow(t-60, -0.1332047592)
+		g = 288.1221695283 * math.Pow(t-60, -0.0755148492)
+	}
+	switch {
+	case t >= 66:
+		b = 255
+	case t <= 19:
+		b = 0
+	default:
+		b = 138.5177312231*math.Log(t-10) - 305.0447927307
+	}
+	return clampUnit(r / 255), clampUnit(g / 255), clampUnit(b / 255)
+}
+
+// unit returns the color's channels in [0, 1].
+func (c Color) unit() (r, g, b float64) {
+	c = NewColor(c.R, c.G, c.B)
+	return float64(c.R) / 255, float64(c.G) / 255, float64(c.B) / 255
+}
+
+// hue returns the hue in degrees of a color with the given channels,
+// largest channel and chroma.
+func hue(r, g, b, maxC, chroma float64) float64 {
+	var h float64
+	switch {
+	case chroma == 0:
+		return 0
+	case maxC == r:
+		h = math.Mod((g-b)/chroma, 6)
+	case maxC == g:
+		h = (b-r)/chroma + 2
+	default:
+		h = (r-g)/chroma + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+// fromHueChroma returns the color with hue h in degrees and chroma, with
+// m added to every channel.
+func fromHueChroma(h, chroma, m float64) Color {
+	if math.IsNaN(h) || math.IsInf(h, 0) {
+		h = 0
+	}
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	sector := h / 60
+	x := chroma * (1 - math.Abs(math.Mod(sector, 2)-1))
+
+	var r, g, b float64
+	switch {
+	case sector < 1:
+		r, g = chroma, x
+	case sector < 2:
+		r, g = x, chroma
+	case sector < 3:
+		g, b = chroma, x
+	case sector < 4:
+		g, b = x, chroma
+	case sector < 5:
+		r, b = x, chroma
+	default:
+		r, b = chroma, x
+	}
+	return Color{R: unitToChannel(r + m), G: unitToChannel(g + m), B: unitToChannel(b + m)}
+}
+
+// clampUnit clamps v to [0, 1], mapping NaN to 0.
+func clampUnit(v float64) float64 {
+	if math.IsNaN(v) {
+		return 0
+	}
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,111 @@
+package govee
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColorHSV(t *testing.T) {
+	tests := []struct {
+		color   Color
+		h, s, v float64
+	}{
+		{Black, 0, 0, 0},
+		{White, 0, 0, 1},
+		{Red, 0, 1, 1},
+		{Lime, 120, 1, 1},
+		{Blue, 240, 1, 1},
+		{Magenta, 300, 1, 1},
+		{Color{R: 255, G: 136}, 32, 1, 1},
+		{Gray, 0, 0, 128.0 / 255},
+	}
+	for _, tt := range tests {
+		h, s, v := tt.color.HSV()
+		assert.InDelta(t, tt.h, h, 0.001, tt.color)
+		assert.InDelta(t, tt.s, s, 0.001, tt.color)
+		assert.InDelta(t, tt.v, v, 0.001, tt.color)
+		assert.Equal(t, tt.color, FromHSV(h, s, v), tt.color)
+	}
+}
+
+func TestFromHSVRoundTrip(t *testing.T) {
+	for r := uint(0); r <= 255; r += 17 {
+		for g := uint(0); g <= 255; g += 17 {
+			for b := uint(0); b <= 255; b += 17 {
+				c := NewColor(r, g, b)
+				assert.Equal(t, c, FromHSV(c.HSV()), c)
+			}
+		}
+	}
+}
+
+func TestFromHSVOutOfRange(t *testing.T) {
+	assert.Equal(t, Red, FromHSV(360, 1, 1))
+	assert.Equal(t, Blue, FromHSV(-120, 1, 1))
+	assert.Equal(t, White, FromHSV(0, -1, 2))
+	assert.Equal(t, Black, FromHSV(math.NaN(), math.NaN(), math.NaN()))
+}
+
+func TestFromHSL(t *testing.T) {
+	assert.Equal(t, Red, FromHSL(0, 1, 0.5))
+	assert.Equal(t, White, FromHSL(0, 1, 1))
+	assert.Equal(t, Black, FromHSL(200, 1, 0))
+	assert.Equal(t, Coral, FromHSL(16.1, 1, 0.657))
+	assert.Equal(t, Color{R: 64, G: 191, B: 191}, FromHSL(180, 0.5, 0.5))
+	assert.Equal(t, Color{R: 128, G: 128, B: 128}, FromHSL(90, 0, 0.5))
+}
+
+func TestParseHex(t *testing.T) {
+	tests := map[string]Color{
+		"#ff8800": {R: 255, G: 136},
+		"FF8800":  {R: 255, G: 136},
+		"#f80":    {R: 255, G: 136},
+		"#000":    Black,
+		"#FFFFFF": White,
+	}
+	for input, want := range tests {
+		got, err := ParseHex(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	for _, input := range []string{"", "#", "#12345", "#1234567", "#gggggg", "coral", "+12345", "0x1234"} {
+		_, err := ParseHex(input)
+		assert.ErrorIs(t, err, ErrInvalidColor, input)
+	}
+}
+
+func TestColorHex(t *testing.T) {
+	assert.Equal(t, "#ff7f50", Coral.Hex())
+	assert.Equal(t, "#000000", Black.Hex())
+	assert.Equal(t, "#ff0001", Color{R: 300, B: 1}.Hex())
+
+	c, err := ParseHex(Cornflowerblue.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, Cornflowerblue, c)
+}
+
+func TestKelvinToRGB(t *testing.T) {
+	assert.Equal(t, Color{R: 255, G: 137, B: 14}, KelvinToRGB(2000))
+	assert.Equal(t, Color{R: 255, G: 167, B: 87}, KelvinToRGB(2700))
+	assert.Equal(t, Color{R: 255, G: 254, B: 250}, KelvinToRGB(6500))
+	assert.Equal(t, Color{R: 210, G: 223, B: 255}, KelvinToRGB(9000))
+
+	// Warmer temperatures are redder.
+	for k := ColorKelvin(2000); k < 9000; k += 100 {
+		warm, cool := KelvinToRGB(k), KelvinToRGB(k+100)
+		assert.GreaterOrEqual(t, float64(cool.B)/float64(cool.R), float64(warm.B)/float64(warm.R), k)
+	}
+}
+
+func TestRGBToKelvin(t *testing.T) {
+	for k := ColorKelvin(2000); k <= 9000; k += 250 {
+		assert.InDelta(t, float64(k), float64(RGBToKelvin(KelvinToRGB(k))), 100, k)
+	}
+	assert.Equal(t, ColorKelvin(2000), RGBToKelvin(Red))
+	assert.Equal(t, ColorKelvin(9000), RGBToKelvin(Blue))
+	assert.Equal(t, ColorKelvin(2000), RGBToKelvin(Color{R: 400}))
+}
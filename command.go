@@ -0,0 +1,76 @@
+package govee
+
+// Command is a control request that can be sent to a Device with
+// Device.Do. Commands are created with the constructor functions below.
+type Command struct {
+	cmd  string
+	data any
+
+	// applied reports whether a device state shows the command took
+	// effect. It is nil for commands that do not change state.
+	applied func(DeviceState) bool
+}
+
+// TurnOnCommand returns a Command that turns a device on.
+func TurnOnCommand() Command {
+	return Command{
+		cmd:     "turn",
+		data:    onOffRequest{Value: 1},
+		applied: func(s DeviceState) bool { return s.State == 1 },
+	}
+}
+
+// TurnOffCommand returns a Command that turns a device off.
+func TurnOffCommand() Command {
+	return Command{
+		cmd:     "turn",
+		data:    onOffRequest{Value: 0},
+		applied: func(s DeviceState) bool { return s.State == 0 },
+	}
+}
+
+// BrightnessCommand returns a Command that sets the brightness of a device.
+func BrightnessCommand(brightness Brightness) Command {
+	return Command{
+		cmd:     "brightness",
+		data:    brightnessRequest{Value: brightness},
+		applied: func(s DeviceState) bool { return s.Brightness == brightness },
+	}
+}
+
+// ColorCommand returns a Command that sets the color of a device.
+func ColorCommand(color Color) Command {
+	return Command{
+		cmd:     "colorwc",
+		data:    colorRequest{Color: color, Kelvin: 0},
+		applied: func(s DeviceState) bool { return s.Color == color },
+	}
+}
+
+// ColorKelvinCommand returns a Command that sets the color temperature of a device.
+func ColorKelvinCommand(colorKelvin ColorKelvin) Command {
+	return Command{
+		cmd:     "colorwc",
+		data:    colorRequest{Color: Color{}, Kelvin: colorKelvin},
+		applied: func(s DeviceState) bool { return s.ColorKelvin == colorKelvin },
+	}
+}
+
+// statusCommand returns a Command that asks a device to report its status.
+func statusCommand() Command {
+	return Command{cmd: "devStatus", data: devStatusRequest{}}
+}
+
+// String returns the LAN API name of the command.
+func (c Command) String() string {
+	return c.cmd
+}
+
+// message wraps the command in an API request addressed to ip.
+func (c Command) message(ip string) (Message, error) {
+	wrapper, err := newAPIRequest(c.cmd, c.data)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{IP: ip, Payload: wrapper}, nil
+}
@@ -0,0 +1,35 @@
+package govee
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  Command
+		want string
+	}{
+		{"turn on", TurnOnCommand(), `{"msg":{"cmd":"turn","data":{"value":1}}}`},
+		{"turn off", TurnOffCommand(), `{"msg":{"cmd":"turn","data":{"value":0}}}`},
+		{"brightness", BrightnessCommand(NewBrightness(40)), `{"msg":{"cmd":"brightness","data":{"value":40}}}`},
+		{"color", ColorCommand(NewColor(255, 0, 0)), `{"msg":{"cmd":"colorwc","data":{"color":{"r":255,"g":0,"b":0},"colorTemInKelvin":0}}}`},
+		{"color kelvin", ColorKelvinCommand(NewColorKelvin(3500)), `{"msg":{"cmd":"colorwc","data":{"color":{"r":0,"g":0,"b":0},"colorTemInKelvin":3500}}}`},
+		{"status", statusCommand(), `{"msg":{"cmd":"devStatus","data":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := tt.cmd.message("192.168.1.100")
+			assert.NoError(t, err)
+			assert.Equal(t, "192.168.1.100", msg.IP)
+
+			data, err := json.Marshal(msg.Payload)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(data))
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package govee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Defaults used for zero fields of ConfirmOptions.
+const (
+	DefaultConfirmTimeout    = 5 * time.Second
+	DefaultConfirmBackoff    = 100 * time.Millisecond
+	DefaultConfirmMaxBackoff = time.Second
+)
+
+// ConfirmOptions controls how DoConfirmed verifies that a command took
+// effect. Zero fields use the Default values above.
+type ConfirmOptions struct {
+	// Timeout is how long to keep retrying before giving up with
+	// ErrNotConfirmed.
+	Timeout time.Duration
+	// Backoff is how long to wait for a status response after the
+	// first attempt. It doubles after every failed attempt.
+	Backoff time.Duration
+	// MaxBackoff caps the wait between attempts.
+	MaxBackoff time.Duration
+}
+
+// withDefaults returns o with zero fields replaced by their defaults.
+func (o ConfirmOptions) withDefaults() ConfirmOptions {
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultConfirmTimeout
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = DefaultConfirmBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = DefaultConfirmMaxBackoff
+	}
+	return o
+}
+
+// DoConfirmed sends cmd and then polls the device status until it reports
+// the state the command asked for. The LAN API never acknowledges
+// commands, so this is the only way to know a command was not lost.
+// Attempts are retried with exponential backoff; if the state still does
+// not match when opts.Timeout expires, an error wrapping ErrNotConfirmed
+// is returned. If ctx expires first, ctx.Err() is returned.
+func (d *Device) DoConfirmed(ctx context.Context, cmd Command, opts ConfirmOptions) error {
+	if cmd.applied == nil {
+		return d.Do(ctx, cmd)
+	}
+	opts = opts.withDefaults()
+
+	confirmCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	backoff := opts.Backoff
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		d.logger.Debug("Sending confirmed command", "cmd", cmd, "attempt", attempt)
+		next := time.NewTimer(backoff)
+		var state DeviceState
+		err := d.Do(confirmCtx, cmd)
+		if err == nil {
+			attemptCtx, attemptCancel := context.WithTimeout(confirmCtx, backoff)
+			state, err = d.requestStatus(attemptCtx)
+			attemptCancel()
+		}
+		if err == nil && cmd.applied(state) {
+			next.Stop()
+			d.logger.Debug("Command confirmed", "cmd", cmd, "attempt", attempt)
+			return nil
+		}
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			lastErr = err
+		}
+
+		// Space attempts at least backoff apart.
+		select {
+		case <-ctx.Done():
+			next.Stop()
+			return ctx.Err()
+		case <-confirmCtx.Done():
+			next.Stop()
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if lastErr != nil {
+				return fmt.Errorf("%w after %d attempts: %w", ErrNotConfirmed, attempt, lastErr)
+			}
+			return fmt.Errorf("%w after %d attempts", ErrNotConfirmed, attempt)
+		case <-next.C:
+		}
+		backoff = min(backoff*2, opts.MaxBackoff)
+	}
+}
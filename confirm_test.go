@@ -0,0 +1,61 @@
+package govee_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+	"github.com/swrm-io/go-vee/goveetest"
+)
+
+func TestDoConfirmed(t *testing.T) {
+	_, device, sim := startSimulated(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, device.DoConfirmed(ctx, govee.ColorKelvinCommand(3500), govee.ConfirmOptions{}))
+	assert.Equal(t, govee.ColorKelvin(3500), device.ColorKelvin())
+	assert.Equal(t, govee.ColorKelvin(3500), sim.State().ColorKelvin)
+	assert.Equal(t, []string{"scan", "colorwc", "devStatus"}, sim.Commands())
+}
+
+func TestDoConfirmedRetries(t *testing.T) {
+	// Replies arrive after the first attempt has given up waiting.
+	_, device, sim := startSimulated(t, goveetest.WithLatency(60*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := device.DoConfirmed(ctx, govee.BrightnessCommand(40), govee.ConfirmOptions{
+		Timeout:    4 * time.Second,
+		Backoff:    20 * time.Millisecond,
+		MaxBackoff: 200 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, govee.Brightness(40), sim.State().Brightness)
+	assert.Eventually(t, func() bool { return len(sim.Commands()) > 3 }, time.Second, 10*time.Millisecond, "expected more than one attempt")
+}
+
+func TestDoConfirmedNotConfirmed(t *testing.T) {
+	_, device, sim := startSimulated(t)
+	// Once discovered, drop everything so the command never lands.
+	require.NoError(t, sim.Close())
+
+	err := device.DoConfirmed(context.Background(), govee.TurnOnCommand(), govee.ConfirmOptions{
+		Timeout: 200 * time.Millisecond,
+		Backoff: 20 * time.Millisecond,
+	})
+	assert.ErrorIs(t, err, govee.ErrNotConfirmed)
+}
+
+func TestDoConfirmedContextCanceled(t *testing.T) {
+	_, device, sim := startSimulated(t)
+	require.NoError(t, sim.Close())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := device.DoConfirmed(ctx, govee.TurnOnCommand(), govee.ConfirmOptions{Timeout: time.Second})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
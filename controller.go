@@ -3,141 +3,128 @@ package govee
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// commandQueueSize is the number of commands that may be waiting for the
+// sender goroutine before non-blocking sends fail with ErrQueueFull.
+const commandQueueSize = 16
+
 // Controller manages Govee devices and communication over the network.
 type Controller struct {
-	logger  *slog.Logger
+	logger *slog.Logger
+
+	// mu guards the device registry, its indexes and the status held
+	// for devices no scan has identified yet. added is closed and
+	// replaced whenever AddDevice registers a device ID, to wake
+	// WaitForDevice.
+	mu      sync.RWMutex
 	devices []*Device
-	ctx     context.Context
-	cancel  context.CancelFunc
-	command chan Message
-	wg      sync.WaitGroup
+	byIP    map[string]*Device
+	byID    map[string]*Device
+	pending map[string]pendingStatus
+	added   chan struct{}
+
+	// probes carries statically registered devices to the scan goroutine
+	// so they are queried as soon as they are added.
+	probes chan *Device
+
+	// groupsMu guards the named device groups.
+	groupsMu sync.RWMutex
+	groups   map[string]*Group
+
+	// watchersMu guards the scan response watchers.
+	watchersMu sync.Mutex
+	watchers   map[*scanWatcher]scanWatcher
+
+	// subsMu guards the event subscribers.
+	subsMu sync.Mutex
+	subs   map[*subscription]struct{}
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	command   chan Message
+	wg        sync.WaitGroup
+	config    config
+	transport Transport
+	running   atomic.Bool
+	stats     stats
+
+	// started is closed once the controller accepts commands.
+	started chan struct{}
 }
 
 // NewController creates a new Controller with the provided logger.
-func NewController(logger *slog.Logger) *Controller {
+// Options override the Govee LAN API defaults.
+func NewController(logger *slog.Logger, opts ...Option) *Controller {
 	ctx, cancel := context.WithCancel(context.Background())
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 	return &Controller{
-		devices: []*Device{},
-		logger:  logger,
-		ctx:     ctx,
-		cancel:  cancel,
-		command: make(chan Message),
+		devices:  []*Device{},
+		byIP:     map[string]*Device{},
+		byID:     map[string]*Device{},
+		pending:  map[string]pendingStatus{},
+		added:    make(chan struct{}),
+		probes:   make(chan *Device, commandQueueSize),
+		groups:   map[string]*Group{},
+		subs:     map[*subscription]struct{}{},
+		watchers: map[*scanWatcher]scanWatcher{},
+		logger:   logger,
+		ctx:      ctx,
+		cancel:   cancel,
+		command:  make(chan Message, commandQueueSize),
+		config:   cfg,
+		started:  make(chan struct{}),
 	}
 }
 
-// Start initializes the controller, begins listening for device messages, and starts periodic scanning for devices (every 60 seconds). Returns an error if the network cannot be initialized.
+// Start initializes the controller, begins listening for device messages, and starts periodic scanning for devices (every 60 seconds by default). Returns an error if the network cannot be initialized.
 func (c *Controller) Start() error {
 	c.logger.Info("Starting Govee Controller")
-	addr, err := net.ResolveUDPAddr("udp4", "239.255.255.250:4002")
-	if err != nil {
-		c.logger.Error("Failed to resolve UDP address", "error", err)
-		return err
-	}
-
-	conn, err := net.ListenMulticastUDP("udp4", nil, addr)
-	if err != nil {
-		c.logger.Error("Failed to listen on multicast UDP", "error", err)
-		return err
-	}
-	// Don't defer conn.Close() here, close in Shutdown
-
-	err = conn.SetReadBuffer(8192)
-	if err != nil {
-		c.logger.Error("Failed to set UDP read buffer", "error", err)
-		return err
+	transport := c.config.transport
+	if transport == nil {
+		udp, err := newUDPTransport(c.config, c.logger)
+		if err != nil {
+			return err
+		}
+		transport = udp
 	}
+	c.transport = transport
 
-	// Main UDP listener goroutine
-	c.logger.Debug("WG Add: UDP listener goroutine")
+	// Main listener goroutine
+	c.logger.Debug("WG Add: listener goroutine")
 	c.wg.Add(1)
 	go func() {
-		c.logger.Debug("UDP listener goroutine started")
+		c.logger.Debug("listener goroutine started")
 		defer func() {
-			c.logger.Debug("UDP listener goroutine exiting, calling WG Done")
+			c.logger.Debug("listener goroutine exiting, calling WG Done")
 			c.wg.Done()
 		}()
 		for {
-			select {
-			case <-c.ctx.Done():
-				return
-			default:
-				// Set a short read deadline so we can check ctx.Done() regularly
-				_ = conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
-				buffer := make([]byte, 8192)
-				n, src, err := conn.ReadFromUDP(buffer)
-				if err != nil {
-					// If timeout, just continue to check ctx.Done()
-					if ne, ok := err.(net.Error); ok && ne.Timeout() {
-						continue
-					}
-					c.logger.Error("Error reading from UDP", "error", err)
-					continue
-				}
-
-				srcAddr := src.IP.String()
-				device, err := c.DeviceByIP(srcAddr)
-
-				// New device discovered, register it and start its handler.
-				if err != nil {
-					c.logger.Debug("Discovered new device", "ip", srcAddr)
-
-					deviceLogger := c.logger.With("device_ip", srcAddr)
-					newDevice := Device{
-						ip:           srcAddr,
-						logger:       deviceLogger,
-						ctx:          c.ctx,
-						command:      c.command,
-						response:     make(chan Message),
-						statusUpdate: make(chan time.Time, 1),
-					}
-					go newDevice.handler()
-					c.devices = append(c.devices, &newDevice)
-					device = &newDevice
+			src, request, err := transport.Receive(c.ctx)
+			if err != nil {
+				if c.ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+					return
 				}
-
-				// Parse incoming message
-				var request wrapper
-				err = json.Unmarshal(buffer[:n], &request)
-				if err != nil {
-					c.logger.Error("Invalid API Request", "error", err)
+				if errors.Is(err, ErrInvalidMessage) {
+					c.stats.parseErrors.Add(1)
+					c.logger.Error("Invalid API Request", "from", src, "error", err)
 					continue
 				}
-
-				// Handle incoming command and dispatch to device handler
-				switch request.MSG.CMD {
-				case "scan":
-					c.logger.Debug("Received scan response", "from", srcAddr)
-					msg := scanResponse{}
-					err = json.Unmarshal(request.MSG.Data, &msg)
-					if err != nil {
-						c.logger.Error("Invalid scan response", "error", err)
-						continue
-					}
-
-					device.response <- Message{IP: srcAddr, Payload: msg}
-
-				case "devStatus":
-					c.logger.Debug("Received device status", "from", srcAddr)
-					msg := devStatusResponse{}
-					err = json.Unmarshal(request.MSG.Data, &msg)
-					if err != nil {
-						c.logger.Error("Invalid device status response", "error", err)
-						continue
-					}
-
-					device.response <- Message{IP: srcAddr, Payload: msg}
-
-				default:
-					c.logger.Warn("Unknown command received", "cmd", request.MSG.CMD)
-				}
+				c.logger.Error("Error receiving message", "error", err)
+				continue
 			}
+			c.handleMessage(src, request)
 		}
 	}()
 
@@ -149,43 +136,23 @@ func (c *Controller) Start() error {
 			c.logger.Debug("command sender goroutine exiting, calling WG Done")
 			c.wg.Done()
 		}()
-		for cmd := range c.command {
-			data, err := json.Marshal(cmd.Payload)
-			if err != nil {
-				c.logger.Error("Failed to marshal command", "error", err)
-				continue
-			}
-
-			var target string
-			if cmd.IP == "239.255.255.250" {
-				target = fmt.Sprintf("%s:4001", cmd.IP)
-			} else {
-				target = fmt.Sprintf("%s:4003", cmd.IP)
-			}
-
-			addr, err := net.ResolveUDPAddr("udp4", target)
-			if err != nil {
-				c.logger.Error("Failed to resolve device address", "error", err)
-				continue
-			}
-
-			deviceConn, err := net.DialUDP("udp4", nil, addr)
-			if err != nil {
-				c.logger.Error("Failed to dial device address", "error", err)
-				continue
-			}
-
-			_, err = deviceConn.Write(data)
-			if err != nil {
-				c.logger.Error("Failed to send command", "error", err)
-				deviceConn.Close()
-				continue
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case cmd := <-c.command:
+				err := c.write(cmd)
+				if cmd.result != nil {
+					cmd.result <- err
+				}
 			}
-
-			deviceConn.Close()
 		}
 	}()
 
+	// Commands can be accepted once the sender goroutine is running.
+	c.running.Store(true)
+	close(c.started)
+
 	c.logger.Debug("WG Add: periodic scan goroutine")
 	c.wg.Add(1)
 	go func() {
@@ -194,71 +161,280 @@ func (c *Controller) Start() error {
 			c.logger.Debug("periodic scan goroutine exiting, calling WG Done")
 			c.wg.Done()
 		}()
-		ticker := time.NewTicker(60 * time.Second)
+		ticker := time.NewTicker(c.config.scanInterval)
 		defer ticker.Stop()
-		scan, err := newAPIRequest("scan", scanRequest{AccountTopic: "reserve"})
-		if err != nil {
-			c.logger.Error("Failed to create scan request", "error", err)
-			return
-		}
-		msg := Message{"239.255.255.250", scan}
 
 		// send immediate scan on startup
-		c.command <- msg
+		if err := c.scan(c.ctx); err != nil {
+			c.logger.Error("Failed to send scan request", "error", err)
+		}
 
 		for {
 			select {
 			case <-c.ctx.Done():
 				return
 			case <-ticker.C:
+				c.evict()
 				c.logger.Debug("Sending periodic scan request")
-				c.command <- msg
+				if err := c.scan(c.ctx); err != nil {
+					c.logger.Error("Failed to send scan request", "error", err)
+				}
+			case device := <-c.probes:
+				if err := c.probe(c.ctx, device); err != nil {
+					c.logger.Error("Failed to probe device", "device", device, "error", err)
+				}
+			}
+		}
+	}()
+
+	if c.config.pollInterval > 0 {
+		c.logger.Debug("WG Add: status poll goroutine")
+		c.wg.Add(1)
+		go func() {
+			c.logger.Debug("status poll goroutine started")
+			defer func() {
+				c.logger.Debug("status poll goroutine exiting, calling WG Done")
+				c.wg.Done()
+			}()
+			c.poll(c.ctx)
+		}()
+	}
+
+	c.logger.Debug("WG Add: activity check goroutine")
+	c.wg.Add(1)
+	go func() {
+		c.logger.Debug("activity check goroutine started")
+		defer func() {
+			c.logger.Debug("activity check goroutine exiting, calling WG Done")
+			c.wg.Done()
+		}()
+		ticker := time.NewTicker(c.activityInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+				c.checkActivity()
 			}
 		}
 	}()
 
 	<-c.ctx.Done()
+	c.running.Store(false)
 	// Wait for all goroutines to finish
 	c.logger.Debug("WG Wait: waiting for all goroutines to finish")
-	conn.Close()
-	close(c.command)
+	if err := transport.Close(); err != nil {
+		c.logger.Error("Failed to close transport", "error", err)
+	}
 	c.wg.Wait()
 	c.logger.Debug("WG Wait: all goroutines finished")
 	return nil
 }
 
-// Shutdown gracefully shuts down the controller and all goroutines. Blocks until all background tasks have exited.
-func (c *Controller) Shutdown() error {
-	c.logger.Info("Shutting down Govee Controller")
-	c.cancel()
-	c.logger.Debug("Shutdown: waiting for WaitGroup")
-	// c.command will be closed by Start after context is canceled
-	c.wg.Wait()
-	c.logger.Debug("Shutdown: WaitGroup finished")
-	return nil
+// scan sends a scan request to the multicast group and probes every
+// statically registered device.
+func (c *Controller) scan(ctx context.Context) error {
+	msg, err := scanMessage(c.config.multicastAddress)
+	if err != nil {
+		return err
+	}
+	errs := []error{c.send(ctx, msg)}
+	for _, device := range c.staticDevices() {
+		errs = append(errs, c.probe(ctx, device))
+	}
+	return errors.Join(errs...)
 }
 
-// Devices returns a slice of all managed devices.
-func (c *Controller) Devices() []*Device {
-	return c.devices
+// probe unicasts a scan and a status request to device, so devices on
+// networks that drop multicast still report their metadata and state.
+func (c *Controller) probe(ctx context.Context, device *Device) error {
+	msg, err := scanMessage(device.IP())
+	if err != nil {
+		return err
+	}
+	if err := c.send(ctx, msg); err != nil {
+		return err
+	}
+	return device.do(ctx, statusCommand())
 }
 
-// DeviceByIP returns a pointer to a device by its IP address, or an error if not found.
-func (c *Controller) DeviceByIP(ip string) (*Device, error) {
-	for _, device := range c.devices {
-		if device.ip == ip {
-			return device, nil
+// scanMessage returns a scan request addressed to ip.
+func scanMessage(ip string) (Message, error) {
+	scan, err := newAPIRequest("scan", scanRequest{AccountTopic: "reserve"})
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{IP: ip, Payload: scan}, nil
+}
+
+// handleMessage dispatches a message received from srcAddr to the handler
+// of the device it came from. Scan responses register new devices and
+// re-address known ones; status responses from addresses no scan has
+// identified yet are held as pending.
+func (c *Controller) handleMessage(srcAddr string, request *Wrapper) {
+	// Handle incoming command and dispatch to device handler
+	switch request.MSG.CMD {
+	case "scan":
+		c.logger.Debug("Received scan response", "from", srcAddr)
+		c.stats.packetReceived("scan")
+		msg := scanResponse{}
+		err := json.Unmarshal(request.MSG.Data, &msg)
+		if err != nil {
+			c.stats.parseErrors.Add(1)
+			c.logger.Error("Invalid scan response", "error", err)
+			return
+		}
+		if msg.IP == "" {
+			msg.IP = srcAddr
 		}
+
+		device, pending := c.identify(srcAddr, msg.IP, msg.DeviceID)
+		c.dispatch(device, Message{IP: srcAddr, Payload: msg})
+		if pending != nil {
+			c.dispatch(device, Message{IP: srcAddr, Payload: *pending})
+		}
+		c.notifyScan(device, msg)
+
+	case "devStatus":
+		c.logger.Debug("Received device status", "from", srcAddr)
+		c.stats.packetReceived("devStatus")
+		msg := devStatusResponse{}
+		err := json.Unmarshal(request.MSG.Data, &msg)
+		if err != nil {
+			c.stats.parseErrors.Add(1)
+			c.logger.Error("Invalid device status response", "error", err)
+			return
+		}
+
+		device, err := c.DeviceByIP(srcAddr)
+		if err != nil {
+			c.hold(srcAddr, msg)
+			return
+		}
+		c.dispatch(device, Message{IP: srcAddr, Payload: msg})
+
+	default:
+		c.stats.unknownCommands.Add(1)
+		c.logger.Warn("Unknown command received", "cmd", request.MSG.CMD)
 	}
-	return nil, ErrNoDeviceFound
 }
 
-// DeviceByID returns a pointer to a device by its DeviceID, or an error if not found.
-func (c *Controller) DeviceByID(id string) (*Device, error) {
-	for _, device := range c.devices {
-		if device.deviceID == id {
-			return device, nil
+// dispatch hands a message to a device handler, giving up if the device
+// has been removed or the controller is shutting down.
+func (c *Controller) dispatch(device *Device, msg Message) {
+	select {
+	case device.response <- msg:
+	case <-device.ctx.Done():
+	}
+}
+
+// write sends a message to its destination through the transport. Scan
+// requests go to the scan port, everything else to the device command
+// port. Errors wrap ErrSendFailed.
+func (c *Controller) write(cmd Message) error {
+	request, ok := cmd.Payload.(*Wrapper)
+	if !ok {
+		c.stats.sendErrors.Add(1)
+		c.logger.Error("Invalid command payload", "type", fmt.Sprintf("%T", cmd.Payload))
+		return fmt.Errorf("%w: invalid payload %T", ErrSendFailed, cmd.Payload)
+	}
+
+	port := c.config.commandPort
+	if request.MSG.CMD == "scan" {
+		port = c.config.scanPort
+	}
+	target := net.JoinHostPort(cmd.IP, strconv.Itoa(port))
+
+	err := c.transport.Send(target, request)
+	if err != nil {
+		c.stats.sendErrors.Add(1)
+		c.logger.Error("Failed to send command", "target", target, "error", err)
+		return fmt.Errorf("%w: %w", ErrSendFailed, err)
+	}
+	return nil
+}
+
+// send queues a message for the sender goroutine and blocks until it has
+// been written to the network or ctx expires.
+func (c *Controller) send(ctx context.Context, msg Message) error {
+	result, err := c.queue(ctx, msg)
+	if err != nil {
+		return err
+	}
+	return c.await(ctx, result)
+}
+
+// queue hands a message to the sender goroutine, blocking while the queue
+// is full, and returns the channel the result of the write is delivered
+// on.
+func (c *Controller) queue(ctx context.Context, msg Message) (<-chan error, error) {
+	if !c.running.Load() {
+		return nil, ErrNotStarted
+	}
+	msg.result = make(chan error, 1)
+	select {
+	case c.command <- msg:
+		return msg.result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.ctx.Done():
+		return nil, ErrNotStarted
+	}
+}
+
+// await blocks until the sender reports the result of a queued message
+// or ctx expires.
+func (c *Controller) await(ctx context.Context, result <-chan error) error {
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.ctx.Done():
+		return ErrNotStarted
+	}
+}
+
+// Ready blocks until the controller accepts commands, so callers that run
+// Start in a goroutine can wait for it before sending. It returns
+// ctx.Err() if ctx expires first and ErrNotStarted if the controller
+// shuts down.
+func (c *Controller) Ready(ctx context.Context) error {
+	select {
+	case <-c.started:
+		if c.ctx.Err() != nil {
+			return ErrNotStarted
 		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.ctx.Done():
+		return ErrNotStarted
 	}
-	return nil, ErrNoDeviceFound
+}
+
+// trySend queues a message for the sender goroutine without blocking.
+// It returns ErrQueueFull if the queue has no room.
+func (c *Controller) trySend(msg Message) error {
+	if !c.running.Load() {
+		return ErrNotStarted
+	}
+	select {
+	case c.command <- msg:
+		return nil
+	default:
+		c.stats.droppedCommands.Add(1)
+		return ErrQueueFull
+	}
+}
+
+// Shutdown gracefully shuts down the controller and all goroutines. Blocks until all background tasks have exited.
+func (c *Controller) Shutdown() error {
+	c.logger.Info("Shutting down Govee Controller")
+	c.cancel()
+	c.logger.Debug("Shutdown: waiting for WaitGroup")
+	c.wg.Wait()
+	c.logger.Debug("Shutdown: WaitGroup finished")
+	return nil
 }
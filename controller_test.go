@@ -0,0 +1,256 @@
+package govee
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControllerSendNotStarted(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	err := c.send(context.Background(), Message{IP: "127.0.0.1"})
+	assert.ErrorIs(t, err, ErrNotStarted)
+
+	err = c.trySend(Message{IP: "127.0.0.1"})
+	assert.ErrorIs(t, err, ErrNotStarted)
+}
+
+func TestControllerWriteInvalidPayload(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	err := c.write(Message{IP: "127.0.0.1", Payload: make(chan int)})
+	assert.ErrorIs(t, err, ErrSendFailed)
+}
+
+func TestControllerSendContextExpired(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	c.running.Store(true)
+	for range commandQueueSize {
+		c.command <- Message{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := c.send(ctx, Message{IP: "127.0.0.1"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestControllerTrySendQueueFull(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	c.running.Store(true)
+	for range commandQueueSize {
+		assert.NoError(t, c.trySend(Message{}))
+	}
+	assert.ErrorIs(t, c.trySend(Message{}), ErrQueueFull)
+}
+
+func TestDeviceCancelFadeWaitsForFrame(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	defer c.cancel()
+	c.running.Store(true)
+	c.handleMessage("192.168.1.23", scanPacket("192.168.1.23", "AA:BB"))
+	device, err := c.DeviceByID("AA:BB")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	f := device.startFade(cancel)
+
+	// Hold the lock as a frame being queued would.
+	f.mu.Lock()
+	stopped := make(chan struct{})
+	go func() {
+		device.cancelFade()
+		close(stopped)
+	}()
+	require.Eventually(t, func() bool { return ctx.Err() != nil }, time.Second, time.Millisecond)
+	select {
+	case <-stopped:
+		t.Fatal("cancelFade returned while a frame was being queued")
+	case <-time.After(10 * time.Millisecond):
+	}
+	f.mu.Unlock()
+	<-stopped
+
+	// No frame is queued once the fade has been stopped.
+	err = device.doFrame(ctx, f, BrightnessCommand(50))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, c.command)
+}
+
+// parsePacket parses a raw message as sent by a device.
+func parsePacket(data []byte) *Wrapper {
+	var request Wrapper
+	if err := json.Unmarshal(data, &request); err != nil {
+		panic(err)
+	}
+	return &request
+}
+
+// scanPacket returns a scan response as sent by a device.
+func scanPacket(ip, id string) *Wrapper {
+	return parsePacket(fmt.Appendf(nil, `{"msg":{"cmd":"scan","data":{"ip":%q,"device":%q,"sku":"H6159","bleVersionHard":"3.01.01","bleVersionSoft":"1.03.01","wifiVersionHard":"1.00.10","wifiVersionSoft":"1.02.03"}}}`, ip, id))
+}
+
+// statusPacket returns a devStatus response as sent by a device.
+func statusPacket(onOff, brightness int) *Wrapper {
+	return parsePacket(fmt.Appendf(nil, `{"msg":{"cmd":"devStatus","data":{"onOff":%d,"brightness":%d,"color":{"r":255,"g":0,"b":0},"colorTemInKelvin":0}}}`, onOff, brightness))
+}
+
+func TestControllerHandleMessageRegistersDevice(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	defer c.cancel()
+
+	c.handleMessage("192.168.1.23", scanPacket("192.168.1.23", "1F:80:C5:32:32:36:72:4E"))
+	c.handleMessage("192.168.1.23", statusPacket(1, 42))
+
+	byIP, err := c.DeviceByIP("192.168.1.23")
+	require.NoError(t, err)
+	byID, err := c.DeviceByID("1F:80:C5:32:32:36:72:4E")
+	require.NoError(t, err)
+	assert.Same(t, byIP, byID)
+	assert.Len(t, c.Devices(), 1)
+
+	assert.Eventually(t, func() bool { return byIP.Brightness() == 42 }, time.Second, time.Millisecond)
+	assert.Equal(t, "H6159", byIP.SKU())
+	assert.Equal(t, State(1), byIP.State())
+
+	snapshot := byIP.Snapshot()
+	assert.Equal(t, "192.168.1.23", snapshot.IP)
+	assert.Equal(t, "1F:80:C5:32:32:36:72:4E", snapshot.DeviceID)
+	assert.Equal(t, NewVersion(1, 0, 10), snapshot.WifiVersionHard)
+	assert.Equal(t, Brightness(42), snapshot.Brightness)
+	assert.WithinDuration(t, time.Now(), snapshot.LastSeen, time.Second)
+}
+
+func TestControllerHandleMessageInvalidScan(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	defer c.cancel()
+
+	c.handleMessage("192.168.1.23", parsePacket([]byte(`{"msg":{"cmd":"scan","data":"not a scan"}}`)))
+	assert.Empty(t, c.Devices())
+
+	_, err := c.DeviceByID("unknown")
+	assert.ErrorIs(t, err, ErrNoDeviceFound)
+}
+
+func TestControllerHandleMessageIPChange(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	defer c.cancel()
+
+	c.handleMessage("192.168.1.23", scanPacket("192.168.1.23", "AA:BB"))
+	device, err := c.DeviceByID("AA:BB")
+	require.NoError(t, err)
+
+	c.handleMessage("192.168.1.42", scanPacket("192.168.1.42", "AA:BB"))
+	assert.Len(t, c.Devices(), 1)
+	moved, err := c.DeviceByIP("192.168.1.42")
+	require.NoError(t, err)
+	assert.Same(t, device, moved)
+	_, err = c.DeviceByIP("192.168.1.23")
+	assert.ErrorIs(t, err, ErrNoDeviceFound)
+	assert.Eventually(t, func() bool { return device.IP() == "192.168.1.42" }, time.Second, time.Millisecond)
+
+	// A different device taking over the old address is a new device.
+	c.handleMessage("192.168.1.23", scanPacket("192.168.1.23", "CC:DD"))
+	assert.Len(t, c.Devices(), 2)
+	other, err := c.DeviceByIP("192.168.1.23")
+	require.NoError(t, err)
+	assert.NotSame(t, device, other)
+}
+
+func TestControllerHandleMessagePendingStatus(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	defer c.cancel()
+
+	c.handleMessage("192.168.1.23", statusPacket(1, 42))
+	assert.Empty(t, c.Devices())
+	assert.Equal(t, []string{"192.168.1.23"}, c.PendingIPs())
+
+	c.handleMessage("192.168.1.23", scanPacket("192.168.1.23", "AA:BB"))
+	assert.Empty(t, c.PendingIPs())
+	device, err := c.DeviceByID("AA:BB")
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return device.Brightness() == 42 }, time.Second, time.Millisecond)
+	assert.Equal(t, State(1), device.State())
+}
+
+func TestControllerHandleMessageStaticDevice(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	defer c.cancel()
+
+	device, err := c.AddDevice("192.168.1.23", DeviceOptions{DeviceID: "AA:BB"})
+	require.NoError(t, err)
+	assert.Equal(t, []*Device{device}, c.staticDevices())
+
+	// Later scans update the registered device rather than adding one.
+	c.handleMessage("192.168.1.23", scanPacket("192.168.1.23", "AA:BB"))
+	c.handleMessage("192.168.1.42", scanPacket("192.168.1.42", "AA:BB"))
+	assert.Equal(t, []*Device{device}, c.Devices())
+	assert.Eventually(t, func() bool { return device.IP() == "192.168.1.42" }, time.Second, time.Millisecond)
+}
+
+func TestControllerForget(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	defer c.cancel()
+	c.handleMessage("192.168.1.23", scanPacket("192.168.1.23", "AA:BB"))
+	device, err := c.DeviceByID("AA:BB")
+	require.NoError(t, err)
+
+	events := c.Subscribe(context.Background(), nil)
+	require.NoError(t, c.Forget("AA:BB"))
+	removed, ok := nextEvent(t, events).(DeviceRemoved)
+	require.True(t, ok)
+	assert.Same(t, device, removed.Device)
+	assert.Empty(t, c.Devices())
+	_, err = c.DeviceByIP("192.168.1.23")
+	assert.ErrorIs(t, err, ErrNoDeviceFound)
+	assert.Error(t, device.ctx.Err(), "handler not stopped")
+	assert.ErrorIs(t, c.Forget("AA:BB"), ErrNoDeviceFound)
+
+	// A forgotten device that answers again is a new device.
+	c.handleMessage("192.168.1.23", scanPacket("192.168.1.23", "AA:BB"))
+	again, err := c.DeviceByID("AA:BB")
+	require.NoError(t, err)
+	assert.NotSame(t, device, again)
+
+	// Devices without an ID are forgotten by IP.
+	static, err := c.AddDevice("192.168.1.42", DeviceOptions{})
+	require.NoError(t, err)
+	require.NoError(t, c.Forget("192.168.1.42"))
+	assert.NotContains(t, c.Devices(), static)
+}
+
+func TestControllerEvict(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler), WithScanInterval(time.Minute), WithEviction(3))
+	defer c.cancel()
+	c.handleMessage("192.168.1.23", scanPacket("192.168.1.23", "AA:BB"))
+	c.handleMessage("192.168.1.42", scanPacket("192.168.1.42", "CC:DD"))
+	static, err := c.AddDevice("192.168.1.99", DeviceOptions{})
+	require.NoError(t, err)
+	stale, err := c.DeviceByID("AA:BB")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return stale.Active() }, time.Second, time.Millisecond)
+
+	events := c.Subscribe(context.Background(), func(e Event) bool {
+		_, ok := e.(DeviceRemoved)
+		return ok
+	})
+	for _, d := range []*Device{stale, static} {
+		d.mu.Lock()
+		d.seen = time.Now().Add(-4 * time.Minute)
+		d.mu.Unlock()
+	}
+	c.evict()
+
+	removed := nextEvent(t, events)
+	assert.Same(t, stale, removed.EventDevice())
+	assert.Len(t, c.Devices(), 2)
+	assert.Contains(t, c.Devices(), static)
+	_, err = c.DeviceByID("AA:BB")
+	assert.ErrorIs(t, err, ErrNoDeviceFound)
+}
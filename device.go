@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 )
 
 // Device represents a Govee device with its properties and current state.
 // It manages device state, communication, and provides control methods.
 type Device struct {
+	// mu guards the device properties and state below, which are
+	// written by handler and read by the getters.
+	mu   sync.RWMutex
 	seen time.Time
 
 	ip              string
@@ -25,11 +29,31 @@ type Device struct {
 	color       Color
 	colorKelvin ColorKelvin
 
-	logger       *slog.Logger
-	ctx          context.Context
-	command      chan Message
-	response     chan Message
-	statusUpdate chan time.Time
+	activeWindow time.Duration
+	inactive     bool
+	discovered   bool
+	reported     bool
+
+	// registeredIP and registeredID are the keys the device is indexed
+	// under in the controller registry, and static records whether it was
+	// added with AddDevice. They are guarded by the controller's mu.
+	registeredIP string
+	registeredID string
+	static       bool
+
+	// fadeMu guards the fade in progress.
+	fadeMu sync.Mutex
+	fade   *fade
+
+	logger     *slog.Logger
+	ctx        context.Context
+	cancel     context.CancelFunc
+	controller *Controller
+	response   chan Message
+
+	// statusWaiters receive the state from the next status response,
+	// guarded by mu.
+	statusWaiters map[chan DeviceState]struct{}
 }
 
 // handler listens for device responses and updates device state. Exits when ctx is canceled.
@@ -47,6 +71,9 @@ func (d *Device) handler() {
 			switch payload := resp.Payload.(type) {
 			case scanResponse:
 				d.logger.Info("Discovered device", "ip", payload.IP, "deviceID", payload.DeviceID, "sku", payload.SKU)
+				d.mu.Lock()
+				oldIP, discovered := d.ip, !d.discovered
+				d.discovered = true
 				d.ip = payload.IP
 				d.deviceID = payload.DeviceID
 				d.sku = payload.SKU
@@ -54,18 +81,38 @@ func (d *Device) handler() {
 				d.bleVersionSoft = payload.BleVersionSoft
 				d.wifiVersionHard = payload.WifiVersionHard
 				d.wifiVersionSoft = payload.WifiVersionSoft
-				d.seen = time.Now()
+				cameBack := d.markSeen()
+				d.mu.Unlock()
+
+				if cameBack {
+					d.controller.publish(DeviceCameBack{Device: d})
+				}
+				if discovered {
+					d.controller.publish(DeviceDiscovered{Device: d})
+				}
+				if oldIP != payload.IP {
+					d.controller.publish(DeviceIPChanged{Device: d, OldIP: oldIP, NewIP: payload.IP})
+				}
 
 			case devStatusResponse:
 				d.logger.Info("Device status update", "onOff", payload.OnOff, "brightness", payload.Brightness, "color", payload.Color, "colorKelvin", payload.ColorKelvin)
+				d.mu.Lock()
+				old := d.deviceState()
 				d.state = payload.OnOff
 				d.brightness = payload.Brightness
 				d.color = payload.Color
 				d.colorKelvin = payload.ColorKelvin
-				d.seen = time.Now()
-				select {
-				case d.statusUpdate <- time.Now():
-				default:
+				d.reported = true
+				cameBack := d.markSeen()
+				current := d.deviceState()
+				d.notifyStatus(current)
+				d.mu.Unlock()
+
+				if cameBack {
+					d.controller.publish(DeviceCameBack{Device: d})
+				}
+				if old.Controls() != current.Controls() {
+					d.controller.publish(StateChanged{Device: d, Old: old, New: current})
 				}
 			default:
 				d.logger.Warn("Unknown command type", "type", fmt.Sprintf("%T", resp))
@@ -74,8 +121,58 @@ func (d *Device) handler() {
 	}
 }
 
+// markSeen records that the device has just been heard from and reports
+// whether it had previously been marked inactive. d.mu must be held.
+func (d *Device) markSeen() bool {
+	d.seen = time.Now()
+	cameBack := d.inactive
+	d.inactive = false
+	return cameBack
+}
+
+// markInactive flags the device as inactive if it has not been seen within
+// the active window. It reports whether the device has just become inactive.
+func (d *Device) markInactive() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.inactive || d.seen.IsZero() || time.Since(d.seen) < d.activeWindow {
+		return false
+	}
+	d.inactive = true
+	return true
+}
+
+// Snapshot returns the device's metadata and state, read atomically so
+// that no field reflects a later update than another.
+func (d *Device) Snapshot() DeviceState {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.deviceState()
+}
+
+// deviceState returns the current metadata and state of the device. d.mu
+// must be held.
+func (d *Device) deviceState() DeviceState {
+	return DeviceState{
+		IP:              d.ip,
+		DeviceID:        d.deviceID,
+		SKU:             d.sku,
+		BleVersionHard:  d.bleVersionHard,
+		BleVersionSoft:  d.bleVersionSoft,
+		WifiVersionHard: d.wifiVersionHard,
+		WifiVersionSoft: d.wifiVersionSoft,
+		LastSeen:        d.seen,
+		State:           d.state,
+		Brightness:      d.brightness,
+		Color:           d.color,
+		ColorKelvin:     d.colorKelvin,
+	}
+}
+
 // String returns a string representation of the device.
 func (d *Device) String() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	var sku = "unknown"
 	if d.sku != "" {
 		sku = d.sku
@@ -88,156 +185,213 @@ func (d *Device) String() string {
 	return fmt.Sprintf("%s: %s (%s)", sku, d.ip, deviceID)
 }
 
-// Active returns true if the device has been seen in the last 5 minutes.
+// Active returns true if the device has been seen within the controller's
+// active window (5 minutes by default).
 func (d *Device) Active() bool {
-	return time.Since(d.seen) < 5*time.Minute
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return time.Since(d.seen) < d.activeWindow
 }
 
 // IP returns the device's IP address.
-func (d *Device) IP() string { return d.ip }
+func (d *Device) IP() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.ip
+}
 
 // DeviceID returns the device's unique identifier.
-func (d *Device) DeviceID() string { return d.deviceID }
+func (d *Device) DeviceID() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.deviceID
+}
 
 // SKU returns the device's SKU.
-func (d *Device) SKU() string { return d.sku }
+func (d *Device) SKU() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.sku
+}
 
 // BleVersionHard returns the BLE hardware version.
-func (d *Device) BleVersionHard() Version { return d.bleVersionHard }
+func (d *Device) BleVersionHard() Version {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.bleVersionHard
+}
 
 // BleVersionSoft returns the BLE software version.
-func (d *Device) BleVersionSoft() Version { return d.bleVersionSoft }
+func (d *Device) BleVersionSoft() Version {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.bleVersionSoft
+}
 
 // WifiVersionHard returns the WiFi hardware version.
-func (d *Device) WifiVersionHard() Version { return d.wifiVersionHard }
+func (d *Device) WifiVersionHard() Version {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.wifiVersionHard
+}
 
 // WifiVersionSoft returns the WiFi software version.
-func (d *Device) WifiVersionSoft() Version { return d.wifiVersionSoft }
+func (d *Device) WifiVersionSoft() Version {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.wifiVersionSoft
+}
 
 // State returns the current on/off state of the device.
-func (d *Device) State() State { return d.state }
+func (d *Device) State() State {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.state
+}
 
 // Brightness returns the current brightness of the device.
-func (d *Device) Brightness() Brightness { return d.brightness }
+func (d *Device) Brightness() Brightness {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.brightness
+}
 
 // Color returns the current color of the device.
-func (d *Device) Color() Color { return d.color }
+func (d *Device) Color() Color {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.color
+}
 
 // ColorKelvin returns the current color temperature of the device.
-func (d *Device) ColorKelvin() ColorKelvin { return d.colorKelvin }
+func (d *Device) ColorKelvin() ColorKelvin {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.colorKelvin
+}
 
-// TurnOn turns the device on. Returns an error if the command cannot be sent.
-func (d *Device) TurnOn() error {
-	d.logger.Debug("Sending Turn On command")
-	cmd := onOffRequest{Value: 1}
-	wrapper, err := newAPIRequest("turn", cmd)
+// Do sends cmd to the device and blocks until it has been written to the
+// network or ctx expires. Returns ErrNotStarted if the controller is not
+// running, ctx.Err() if ctx expires first, or an error wrapping
+// ErrSendFailed if the network write fails. Any fade in progress on the
+// device is canceled, and none of its frames are sent after cmd.
+func (d *Device) Do(ctx context.Context, cmd Command) error {
+	d.cancelFade()
+	return d.do(ctx, cmd)
+}
+
+// do sends cmd like Do without canceling a fade in progress.
+func (d *Device) do(ctx context.Context, cmd Command) error {
+	d.logger.Debug("Sending command", "cmd", cmd)
+	msg, err := cmd.message(d.IP())
 	if err != nil {
 		return err
 	}
-	select {
-	case d.command <- Message{IP: d.ip, Payload: wrapper}:
-		return nil
-	default:
-		return fmt.Errorf("failed to send TurnOn command: channel blocked or closed")
-	}
+	return d.controller.send(ctx, msg)
 }
 
-// TurnOff turns the device off. Returns an error if the command cannot be sent.
-func (d *Device) TurnOff() error {
-	d.logger.Debug("Sending Turn Off command")
-	cmd := onOffRequest{Value: 0}
-	wrapper, err := newAPIRequest("turn", cmd)
+// enqueue queues cmd for the sender without blocking. The returned error
+// wraps ErrQueueFull or ErrNotStarted if the command could not be queued.
+func (d *Device) enqueue(name string, cmd Command) error {
+	d.cancelFade()
+	msg, err := cmd.message(d.IP())
 	if err != nil {
 		return err
 	}
-	select {
-	case d.command <- Message{IP: d.ip, Payload: wrapper}:
-		return nil
-	default:
-		return fmt.Errorf("failed to send TurnOff command: channel blocked or closed")
+	if err := d.controller.trySend(msg); err != nil {
+		return fmt.Errorf("failed to send %s command: %w", name, err)
 	}
+	return nil
+}
+
+// TurnOn turns the device on. Returns an error if the command cannot be queued.
+func (d *Device) TurnOn() error {
+	d.logger.Debug("Sending Turn On command")
+	return d.enqueue("TurnOn", TurnOnCommand())
 }
 
-// Toggle toggles the device state. Returns an error if the command cannot be sent.
+// TurnOff turns the device off. Returns an error if the command cannot be queued.
+func (d *Device) TurnOff() error {
+	d.logger.Debug("Sending Turn Off command")
+	return d.enqueue("TurnOff", TurnOffCommand())
+}
+
+// Toggle toggles the device state. Returns an error if the command cannot be queued.
 func (d *Device) Toggle() error {
 	d.logger.Debug("Toggling device state")
-	if d.state == 1 {
+	if d.State() == 1 {
 		return d.TurnOff()
 	}
 	return d.TurnOn()
 }
 
-// SetBrightness sets the brightness of the device. Returns an error if the command cannot be sent.
+// SetBrightness sets the brightness of the device. Returns an error if the command cannot be queued.
 func (d *Device) SetBrightness(brightness Brightness) error {
 	d.logger.Debug("Setting brightness", "brightness", brightness)
-	cmd := brightnessRequest{Value: brightness}
-	wrapper, err := newAPIRequest("brightness", cmd)
-	if err != nil {
-		return err
-	}
-	select {
-	case d.command <- Message{IP: d.ip, Payload: wrapper}:
-		return nil
-	default:
-		return fmt.Errorf("failed to send SetBrightness command: channel blocked or closed")
-	}
+	return d.enqueue("SetBrightness", BrightnessCommand(brightness))
 }
 
-// SetColor sets the color of the device. Returns an error if the command cannot be sent.
+// SetColor sets the color of the device. Returns an error if the command cannot be queued.
 func (d *Device) SetColor(color Color) error {
 	d.logger.Debug("Setting color", "color", color)
-	cmd := colorRequest{Color: color, Kelvin: 0}
-	wrapper, err := newAPIRequest("colorwc", cmd)
-	if err != nil {
-		return err
-	}
-	select {
-	case d.command <- Message{IP: d.ip, Payload: wrapper}:
-		return nil
-	default:
-		return fmt.Errorf("failed to send SetColor command: channel blocked or closed")
-	}
+	return d.enqueue("SetColor", ColorCommand(color))
 }
 
-// SetColorKelvin sets the color temperature of the device. Returns an error if the command cannot be sent.
+// SetColorKelvin sets the color temperature of the device. Returns an error if the command cannot be queued.
 func (d *Device) SetColorKelvin(colorKelvin ColorKelvin) error {
 	d.logger.Debug("Setting color temperature", "colorKelvin", colorKelvin)
-	cmd := colorRequest{Color: Color{}, Kelvin: colorKelvin}
-	wrapper, err := newAPIRequest("colorwc", cmd)
-	if err != nil {
-		return err
-	}
-	select {
-	case d.command <- Message{IP: d.ip, Payload: wrapper}:
-		return nil
-	default:
-		return fmt.Errorf("failed to send SetColorKelvin command: channel blocked or closed")
-	}
+	return d.enqueue("SetColorKelvin", ColorKelvinCommand(colorKelvin))
 }
 
-// RequestStatus requests the current status of the device and blocks until a response is received or times out after 5 seconds. Returns an error if the command cannot be sent or if the response times out.
-func (d *Device) RequestStatus() error {
+// RequestStatus asks the device for its current status and blocks until it
+// responds or ctx expires, returning the reported state. Concurrent callers
+// all receive the first status response that arrives after they asked, so
+// neither can steal the other's reply.
+func (d *Device) RequestStatus(ctx context.Context) (DeviceState, error) {
+	return d.requestStatus(ctx)
+}
+
+// requestStatus sends a devStatus request and waits for the device to
+// report its status or for ctx to expire.
+func (d *Device) requestStatus(ctx context.Context) (DeviceState, error) {
 	d.logger.Debug("Requesting device status")
-	cmd := devStatusRequest{}
-	wrapper, err := newAPIRequest("devStatus", cmd)
-	if err != nil {
-		return err
-	}
-	select {
-	case d.command <- Message{IP: d.ip, Payload: wrapper}:
-	case <-d.ctx.Done():
-		return fmt.Errorf("context canceled while sending RequestStatus command")
-	default:
-		return fmt.Errorf("failed to send RequestStatus command: channel blocked or closed")
+	// Wait before sending so a fast response is not missed.
+	wait := d.waitStatus()
+	defer d.stopWaiting(wait)
+
+	if err := d.do(ctx, statusCommand()); err != nil {
+		return DeviceState{}, fmt.Errorf("failed to send RequestStatus command: %w", err)
 	}
-	// Wait for status update
 	select {
-	case <-d.statusUpdate:
+	case state := <-wait:
 		d.logger.Debug("Received status response")
-		return nil
-	case <-time.After(5 * time.Second):
-		return fmt.Errorf("timeout waiting for device status response")
-	case <-d.ctx.Done():
-		return fmt.Errorf("context canceled while waiting for status response")
+		return state, nil
+	case <-ctx.Done():
+		return DeviceState{}, fmt.Errorf("timeout waiting for device status response: %w", ctx.Err())
+	}
+}
+
+// waitStatus registers a waiter that receives the state from the next
+// status response.
+func (d *Device) waitStatus() chan DeviceState {
+	wait := make(chan DeviceState, 1)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statusWaiters[wait] = struct{}{}
+	return wait
+}
+
+// stopWaiting unregisters a waiter that gave up before a response arrived.
+func (d *Device) stopWaiting(wait chan DeviceState) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.statusWaiters, wait)
+}
+
+// notifyStatus hands state to every pending waiter. d.mu must be held.
+func (d *Device) notifyStatus(state DeviceState) {
+	for wait := range d.statusWaiters {
+		wait <- state
+		delete(d.statusWaiters, wait)
 	}
 }
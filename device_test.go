@@ -1,5 +1,11 @@
 package govee
 
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
 func ExampleDevice_TurnOn() {
 	controller := NewController(nil)
 	go controller.Start()
@@ -74,6 +80,24 @@ func ExampleDevice_RequestStatus() {
 
 	device, _ := controller.DeviceByIP("192.168.1.100")
 	if device != nil {
-		_ = device.RequestStatus()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		state, err := device.RequestStatus(ctx)
+		if err == nil {
+			fmt.Println(state.State, state.Brightness)
+		}
+	}
+}
+
+func ExampleDevice_Do() {
+	controller := NewController(nil)
+	go controller.Start()
+	defer controller.Shutdown()
+
+	device, _ := controller.DeviceByIP("192.168.1.100")
+	if device != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = device.Do(ctx, BrightnessCommand(NewBrightness(50)))
 	}
 }
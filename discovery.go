@@ -0,0 +1,261 @@
+package govee
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxSweepHosts is the largest number of addresses a single Sweep will
+// probe, the size of a /16.
+const maxSweepHosts = 1 << 16
+
+// minSweepInterval is the shortest delay between the scan requests of a
+// Sweep, however high the sweep rate.
+const minSweepInterval = time.Microsecond
+
+// DiscoveryResult describes a device that answered a scan request.
+type DiscoveryResult struct {
+	IP              string  `json:"ip"`
+	DeviceID        string  `json:"deviceID"`
+	SKU             string  `json:"sku"`
+	BleVersionHard  Version `json:"bleVersionHard"`
+	BleVersionSoft  Version `json:"bleVersionSoft"`
+	WifiVersionHard Version `json:"wifiVersionHard"`
+	WifiVersionSoft Version `json:"wifiVersionSoft"`
+
+	// Device is the managed device the response was registered as.
+	Device *Device `json:"-"`
+}
+
+// scanWatcher is called with every scan response the controller receives.
+type scanWatcher func(DiscoveryResult)
+
+// watchScans calls fn from the listener goroutine for every scan response
+// until the returned stop function is called. fn must not block.
+func (c *Controller) watchScans(fn scanWatcher) (stop func()) {
+	key := &fn
+	c.watchersMu.Lock()
+	c.watchers[key] = fn
+	c.watchersMu.Unlock()
+	return func() {
+		c.watchersMu.Lock()
+		delete(c.watchers, key)
+		c.watchersMu.Unlock()
+	}
+}
+
+// notifyScan passes a scan response registered as device to the watchers.
+func (c *Controller) notifyScan(device *Device, msg scanResponse) {
+	result := DiscoveryResult{
+		IP:              msg.IP,
+		DeviceID:        msg.DeviceID,
+		SKU:             msg.SKU,
+		BleVersionHard:  msg.BleVersionHard,
+		BleVersionSoft:  msg.BleVersionSoft,
+		WifiVersionHard: msg.WifiVersionHard,
+		WifiVersionSoft: msg.WifiVersionSoft,
+		Device:          device,
+	}
+	c.watchersMu.Lock()
+	defer c.watchersMu.Unlock()
+	for _, fn := range c.watchers {
+		fn(result)
+	}
+}
+
+// Sweep unicasts a scan request to every host in the given CIDR ranges,
+// for networks where multicast does not reach the lights, and blocks
+// until the sweep wait has passed after the last request. Requests are
+// rate limited to the sweep rate. Devices that answer are registered with
+// the controller like any other discovered device, and one result per
+// answering address within the ranges is returned, sorted by IP.
+//
+// Sweep waits for the controller to start. If ctx expires first, the
+// results collected so far are returned along with ctx.Err().
+func (c *Controller) Sweep(ctx context.Context, cidrs ...string) ([]DiscoveryResult, error) {
+	var hosts []netip.Addr
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sweep range %q: %w", cidr, err)
+		}
+		if !prefix.Addr().Is4() {
+			return nil, fmt.Errorf("invalid sweep range %q: only IPv4 is supported", cidr)
+		}
+		if len(hosts)+1<<(32-prefix.Bits()) > maxSweepHosts {
+			return nil, fmt.Errorf("invalid sweep range %q: more than %d hosts", cidr, maxSweepHosts)
+		}
+		prefixes = append(prefixes, prefix)
+		hosts = append(hosts, prefixHosts(prefix)...)
+	}
+
+	if err := c.Ready(ctx); err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	found := map[string]DiscoveryResult{}
+	stop := c.watchScans(func(result DiscoveryResult) {
+		// Devices answering a concurrent scan from outside the swept
+		// ranges are registered but not part of the results.
+		addr, err := netip.ParseAddr(result.IP)
+		if err != nil || !slices.ContainsFunc(prefixes, func(p netip.Prefix) bool { return p.Contains(addr) }) {
+			return
+		}
+		mu.Lock()
+		found[result.IP] = result
+		mu.Unlock()
+	})
+	defer stop()
+
+	collect := func() []DiscoveryResult {
+		mu.Lock()
+		defer mu.Unlock()
+		results := make([]DiscoveryResult, 0, len(found))
+		for _, result := range found {
+			results = append(results, result)
+		}
+		slices.SortFunc(results, func(a, b DiscoveryResult) int {
+			x, _ := netip.ParseAddr(a.IP)
+			y, _ := netip.ParseAddr(b.IP)
+			return x.Compare(y)
+		})
+		return results
+	}
+
+	c.logger.Info("Sweeping for devices", "ranges", cidrs, "hosts", len(hosts))
+	ticker := time.NewTicker(c.sweepInterval())
+	defer ticker.Stop()
+	for i, host := range hosts {
+		if i > 0 {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return collect(), ctx.Err()
+			}
+		}
+		msg, err := scanMessage(host.String())
+		if err != nil {
+			return collect(), err
+		}
+		if err := c.send(ctx, msg); err != nil {
+			if ctx.Err() != nil {
+				return collect(), ctx.Err()
+			}
+			c.logger.Debug("Failed to send sweep scan", "ip", host, "error", err)
+		}
+	}
+
+	wait := time.NewTimer(c.config.sweepWait)
+	defer wait.Stop()
+	select {
+	case <-wait.C:
+		return collect(), nil
+	case <-ctx.Done():
+		return collect(), ctx.Err()
+	}
+}
+
+// prefixHosts returns the host addresses of an IPv4 prefix. The network
+// and broadcast addresses are skipped for prefixes shorter than /31.
+func prefixHosts(prefix netip.Prefix) []netip.Addr {
+	prefix = prefix.Masked()
+	var hosts []netip.Addr
+	for addr := prefix.Addr(); prefix.Contains(addr); addr = addr.Next() {
+		hosts = append(hosts, addr)
+	}
+	if prefix.Bits() < 31 {
+		hosts = hosts[1 : len(hosts)-1]
+	}
+	return hosts
+}
+
+// Scan sends a scan request immediately, to the multicast group and to
+// every statically registered device, and returns the devices that
+// answered within wait. Scan waits for the controller to start. If ctx
+// expires first, the devices found so far are returned along with
+// ctx.Err().
+func (c *Controller) Scan(ctx context.Context, wait time.Duration) ([]*Device, error) {
+	if err := c.Ready(ctx); err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	var devices []*Device
+	stop := c.watchScans(func(result DiscoveryResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !slices.Contains(devices, result.Device) {
+			devices = append(devices, result.Device)
+		}
+	})
+	defer stop()
+
+	collect := func() []*Device {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(devices)
+	}
+
+	if err := c.scan(ctx); err != nil {
+		return collect(), err
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return collect(), nil
+	case <-ctx.Done():
+		return collect(), ctx.Err()
+	}
+}
+
+// sweepInterval returns the delay between the scan requests of a Sweep.
+func (c *Controller) sweepInterval() time.Duration {
+	return max(time.Second/time.Duration(c.config.sweepRate), minSweepInterval)
+}
+
+// WaitForDevice blocks until a device with the given ID is known to the
+// controller, whether by discovery or AddDevice, and returns it. It
+// returns ctx.Err() if ctx expires first and ErrNotStarted if the
+// controller shuts down.
+func (c *Controller) WaitForDevice(ctx context.Context, id string) (*Device, error) {
+	found := make(chan *Device, 1)
+	stop := c.watchScans(func(result DiscoveryResult) {
+		if result.DeviceID != id {
+			return
+		}
+		select {
+		case found <- result.Device:
+		default:
+		}
+	})
+	defer stop()
+
+	for {
+		// Check after watching so a device discovered or added in
+		// between is not missed.
+		c.mu.RLock()
+		added := c.added
+		c.mu.RUnlock()
+		if device, err := c.DeviceByID(id); err == nil {
+			return device, nil
+		}
+
+		select {
+		case device := <-found:
+			return device, nil
+		case <-added:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.ctx.Done():
+			return nil, ErrNotStarted
+		}
+	}
+}
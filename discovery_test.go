@@ -0,0 +1,86 @@
+package govee
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixHosts(t *testing.T) {
+	hosts := prefixHosts(netip.MustParsePrefix("192.168.1.77/30"))
+	assert.Equal(t, []netip.Addr{
+		netip.MustParseAddr("192.168.1.77"),
+		netip.MustParseAddr("192.168.1.78"),
+	}, hosts)
+
+	assert.Len(t, prefixHosts(netip.MustParsePrefix("10.0.0.0/24")), 254)
+	assert.Len(t, prefixHosts(netip.MustParsePrefix("10.0.0.0/31")), 2)
+	assert.Len(t, prefixHosts(netip.MustParsePrefix("10.0.0.9/32")), 1)
+}
+
+func TestSweepIntervalHighRate(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler), WithSweepRate(math.MaxInt))
+	assert.Equal(t, minSweepInterval, c.sweepInterval())
+}
+
+func TestWaitForDevice(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	defer c.cancel()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		c.handleMessage("192.168.1.42", scanPacket("192.168.1.42", "CC:DD"))
+		c.handleMessage("192.168.1.23", scanPacket("192.168.1.23", "AA:BB"))
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	device, err := c.WaitForDevice(ctx, "AA:BB")
+	require.NoError(t, err)
+	assert.Equal(t, "192.168.1.23", device.IP())
+
+	// Known devices are returned immediately.
+	again, err := c.WaitForDevice(ctx, "AA:BB")
+	require.NoError(t, err)
+	assert.Same(t, device, again)
+
+	short, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = c.WaitForDevice(short, "EE:FF")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWaitForDeviceAdded(t *testing.T) {
+	for i := range 50 {
+		c := NewController(slog.New(slog.DiscardHandler))
+		id := fmt.Sprintf("AA:BB:%02d", i)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		waited := make(chan *Device, 1)
+		go func() {
+			device, err := c.WaitForDevice(ctx, id)
+			assert.NoError(t, err)
+			waited <- device
+		}()
+		if i%2 == 0 {
+			// Let the waiter block first on every other round.
+			require.Eventually(t, func() bool {
+				c.watchersMu.Lock()
+				defer c.watchersMu.Unlock()
+				return len(c.watchers) == 1
+			}, time.Second, time.Millisecond)
+		}
+		added, err := c.AddDevice("192.168.1.23", DeviceOptions{DeviceID: id})
+		require.NoError(t, err)
+
+		assert.Same(t, added, <-waited)
+		cancel()
+		c.cancel()
+	}
+}
@@ -0,0 +1,97 @@
+package govee_test
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+	"github.com/swrm-io/go-vee/goveetest"
+)
+
+func TestDoDelivers(t *testing.T) {
+	_, device, sim := startSimulated(t)
+
+	require.NoError(t, device.Do(context.Background(), govee.TurnOnCommand()))
+	require.Eventually(t, func() bool {
+		return sim.State().State == 1
+	}, time.Second, time.Millisecond)
+	assert.Contains(t, sim.Commands(), "turn")
+}
+
+func TestControllerReady(t *testing.T) {
+	transport := goveetest.NewTransport()
+	c := govee.NewController(slog.New(slog.DiscardHandler), govee.WithTransport(transport))
+	short, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, c.Ready(short), context.DeadlineExceeded)
+
+	go func() { _ = c.Start() }()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, c.Ready(ctx))
+
+	require.NoError(t, c.Shutdown())
+	assert.ErrorIs(t, c.Ready(ctx), govee.ErrNotStarted)
+}
+
+func TestDoAfterShutdown(t *testing.T) {
+	c, transport := startTransport(t)
+	device := announce(t, c, transport, "10.0.0.5", "AA:BB", "H6159")
+	require.NoError(t, c.Shutdown())
+
+	err := device.Do(context.Background(), govee.TurnOnCommand())
+	assert.ErrorIs(t, err, govee.ErrNotStarted)
+}
+
+func TestDoSendFailed(t *testing.T) {
+	c, transport := startTransport(t)
+	device := announce(t, c, transport, "10.0.0.5", "AA:BB", "H6159")
+	require.NoError(t, transport.Close())
+
+	err := device.Do(context.Background(), govee.TurnOnCommand())
+	assert.ErrorIs(t, err, govee.ErrSendFailed)
+	assert.ErrorIs(t, err, net.ErrClosed)
+	assert.Equal(t, uint64(1), c.Stats().SendErrors)
+}
+
+func TestDeviceRequestStatusFanOut(t *testing.T) {
+	const callers = 8
+	c, transport := startTransport(t)
+	device := announce(t, c, transport, "10.0.0.5", "AA:BB", "H6159")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	states := make(chan govee.DeviceState, callers)
+	errs := make(chan error, callers)
+	for range callers {
+		go func() {
+			state, err := device.RequestStatus(ctx)
+			states <- state
+			errs <- err
+		}()
+	}
+	require.Eventually(t, func() bool {
+		return govee.StatusWaiters(device) == callers
+	}, time.Second, time.Millisecond)
+
+	// A single response, solicited or not, answers every caller.
+	reported := govee.DeviceState{State: 1, Brightness: 42, Color: govee.NewColor(255, 0, 0)}
+	require.NoError(t, transport.DeliverStatus("10.0.0.5", reported))
+	for range callers {
+		require.NoError(t, <-errs)
+		state := <-states
+		assert.Equal(t, reported, state.Controls())
+		assert.Equal(t, "AA:BB", state.DeviceID)
+	}
+
+	short, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := device.RequestStatus(short)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Zero(t, govee.StatusWaiters(device))
+}
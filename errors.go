@@ -5,4 +5,13 @@ import "errors"
 var (
 	ErrInvalidVersionFormat = errors.New("invalid version format")
 	ErrNoDeviceFound        = errors.New("no device found")
+	ErrNoGroupFound         = errors.New("no group found")
+	ErrNotStarted           = errors.New("controller not started")
+	ErrQueueFull            = errors.New("command queue full")
+	ErrSendFailed           = errors.New("failed to send command")
+	ErrNotConfirmed         = errors.New("command not confirmed")
+	ErrFadeCanceled         = errors.New("fade canceled by a new command")
+	ErrInvalidDelta         = errors.New("invalid state delta")
+	ErrInvalidColor         = errors.New("invalid color")
+	ErrDeviceConflict       = errors.New("conflicting device registration")
 )
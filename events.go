@@ -0,0 +1,151 @@
+package govee
+
+import (
+	"context"
+	"time"
+)
+
+// eventBufferSize is the number of events buffered for each subscriber.
+// Events for a subscriber whose buffer is full are dropped.
+const eventBufferSize = 64
+
+// Event is a change in the set of devices or in a device's state,
+// delivered to subscribers by Controller.Subscribe. The concrete type is
+// one of DeviceDiscovered, DeviceIPChanged, StateChanged,
+// DeviceWentInactive, DeviceCameBack or DeviceRemoved.
+type Event interface {
+	// EventDevice returns the device the event relates to.
+	EventDevice() *Device
+}
+
+// EventFilter reports whether an event should be delivered to a
+// subscriber. A nil filter accepts every event.
+type EventFilter func(Event) bool
+
+// DeviceDiscovered is emitted when a device first identifies itself in a
+// scan response.
+type DeviceDiscovered struct {
+	Device *Device
+}
+
+// DeviceIPChanged is emitted when a scan response reports a device at a
+// different IP address, or Controller.AddDevice moves it to one.
+type DeviceIPChanged struct {
+	Device *Device
+	OldIP  string
+	NewIP  string
+}
+
+// StateChanged is emitted when a status response reports a state that
+// differs from the last known state of the device.
+type StateChanged struct {
+	Device *Device
+	Old    DeviceState
+	New    DeviceState
+}
+
+// DeviceWentInactive is emitted when a device has not been seen within the
+// controller's active window.
+type DeviceWentInactive struct {
+	Device *Device
+}
+
+// DeviceCameBack is emitted when a device that went inactive is seen again.
+type DeviceCameBack struct {
+	Device *Device
+}
+
+// DeviceRemoved is emitted when a device is evicted after not being seen
+// for the configured number of scan intervals, or removed with
+// Controller.Forget.
+type DeviceRemoved struct {
+	Device *Device
+}
+
+// EventDevice returns the discovered device.
+func (e DeviceDiscovered) EventDevice() *Device { return e.Device }
+
+// EventDevice returns the device whose IP address changed.
+func (e DeviceIPChanged) EventDevice() *Device { return e.Device }
+
+// EventDevice returns the device whose state changed.
+func (e StateChanged) EventDevice() *Device { return e.Device }
+
+// EventDevice returns the device that went inactive.
+func (e DeviceWentInactive) EventDevice() *Device { return e.Device }
+
+// EventDevice returns the device that came back.
+func (e DeviceCameBack) EventDevice() *Device { return e.Device }
+
+// EventDevice returns the removed device.
+func (e DeviceRemoved) EventDevice() *Device { return e.Device }
+
+// subscription is a single subscriber registered with Subscribe.
+type subscription struct {
+	ch     chan Event
+	filter EventFilter
+}
+
+// Subscribe returns a channel of events accepted by filter. The channel is
+// closed when ctx is canceled or the controller shuts down. Delivery never
+// blocks the controller: events are dropped for subscribers that fall
+// behind.
+func (c *Controller) Subscribe(ctx context.Context, filter EventFilter) <-chan Event {
+	sub := &subscription{
+		ch:     make(chan Event, eventBufferSize),
+		filter: filter,
+	}
+
+	c.subsMu.Lock()
+	c.subs[sub] = struct{}{}
+	c.subsMu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-c.ctx.Done():
+		}
+		c.subsMu.Lock()
+		delete(c.subs, sub)
+		close(sub.ch)
+		c.subsMu.Unlock()
+	}()
+	return sub.ch
+}
+
+// publish delivers an event to every subscriber whose filter accepts it.
+func (c *Controller) publish(e Event) {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+	for sub := range c.subs {
+		if sub.filter != nil && !sub.filter(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			c.stats.droppedEvents.Add(1)
+			c.logger.Debug("Dropping event for slow subscriber", "event", e)
+		}
+	}
+}
+
+// minActivityInterval bounds how often devices are checked for
+// inactivity, however short the active window.
+const minActivityInterval = time.Millisecond
+
+// activityInterval returns how often devices are checked for inactivity.
+func (c *Controller) activityInterval() time.Duration {
+	return max(c.config.activeWindow/4, minActivityInterval)
+}
+
+// checkActivity marks devices that have not been seen within the active
+// window as inactive and emits DeviceWentInactive for each of them.
+func (c *Controller) checkActivity() {
+	for _, device := range c.Devices() {
+		if device.markInactive() {
+			device.logger.Info("Device went inactive")
+			c.publish(DeviceWentInactive{Device: device})
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package govee
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nextEvent waits for the next event on ch.
+func nextEvent(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+	select {
+	case e, ok := <-ch:
+		require.True(t, ok, "event channel closed")
+		return e
+	case <-time.After(time.Second):
+		require.FailNow(t, "timed out waiting for event")
+		return nil
+	}
+}
+
+func TestSubscribeDiscoveryAndStateChange(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	defer c.cancel()
+	events := c.Subscribe(context.Background(), nil)
+
+	c.handleMessage("192.168.1.23", scanPacket("192.168.1.23", "AA:BB"))
+	discovered, ok := nextEvent(t, events).(DeviceDiscovered)
+	require.True(t, ok)
+	assert.Equal(t, "AA:BB", discovered.Device.DeviceID())
+
+	c.handleMessage("192.168.1.23", statusPacket(1, 42))
+	changed, ok := nextEvent(t, events).(StateChanged)
+	require.True(t, ok)
+	assert.Same(t, discovered.Device, changed.EventDevice())
+	assert.Equal(t, DeviceState{}, changed.Old.Controls())
+	assert.Equal(t, DeviceState{State: 1, Brightness: 42, Color: Color{R: 255}}, changed.New.Controls())
+	assert.Equal(t, "AA:BB", changed.New.DeviceID)
+
+	// An identical status is not a change.
+	c.handleMessage("192.168.1.23", statusPacket(1, 42))
+	c.handleMessage("192.168.1.23", statusPacket(0, 42))
+	changed, ok = nextEvent(t, events).(StateChanged)
+	require.True(t, ok)
+	assert.Equal(t, State(1), changed.Old.State)
+	assert.Equal(t, State(0), changed.New.State)
+}
+
+func TestSubscribeIPChanged(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	defer c.cancel()
+	c.handleMessage("192.168.1.23", scanPacket("192.168.1.23", "AA:BB"))
+
+	events := c.Subscribe(context.Background(), func(e Event) bool {
+		_, ok := e.(DeviceIPChanged)
+		return ok
+	})
+	c.handleMessage("192.168.1.23", scanPacket("192.168.1.42", "AA:BB"))
+	changed, ok := nextEvent(t, events).(DeviceIPChanged)
+	require.True(t, ok)
+	assert.Equal(t, "192.168.1.23", changed.OldIP)
+	assert.Equal(t, "192.168.1.42", changed.NewIP)
+	assert.Len(t, c.Devices(), 1)
+}
+
+func TestSubscribeFilter(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	defer c.cancel()
+	events := c.Subscribe(context.Background(), func(e Event) bool {
+		_, ok := e.(StateChanged)
+		return ok
+	})
+
+	c.handleMessage("192.168.1.23", scanPacket("192.168.1.23", "AA:BB"))
+	c.handleMessage("192.168.1.23", statusPacket(1, 10))
+	_, ok := nextEvent(t, events).(StateChanged)
+	assert.True(t, ok)
+}
+
+func TestSubscribeInactiveAndCameBack(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler), WithActiveWindow(time.Millisecond))
+	defer c.cancel()
+	c.handleMessage("192.168.1.23", scanPacket("192.168.1.23", "AA:BB"))
+	device, err := c.DeviceByIP("192.168.1.23")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return device.DeviceID() != "" }, time.Second, time.Millisecond)
+
+	events := c.Subscribe(context.Background(), nil)
+	time.Sleep(5 * time.Millisecond)
+	c.checkActivity()
+	c.checkActivity()
+	inactive, ok := nextEvent(t, events).(DeviceWentInactive)
+	require.True(t, ok)
+	assert.Same(t, device, inactive.Device)
+
+	c.handleMessage("192.168.1.23", statusPacket(1, 10))
+	back, ok := nextEvent(t, events).(DeviceCameBack)
+	require.True(t, ok)
+	assert.Same(t, device, back.Device)
+	_, ok = nextEvent(t, events).(StateChanged)
+	assert.True(t, ok)
+}
+
+func TestActivityIntervalShortWindow(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler), WithActiveWindow(time.Nanosecond))
+	assert.Equal(t, minActivityInterval, c.activityInterval())
+}
+
+func TestSubscribeSlowSubscriberDoesNotBlock(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	defer c.cancel()
+	_ = c.Subscribe(context.Background(), nil)
+	c.handleMessage("192.168.1.23", scanPacket("192.168.1.23", "AA:BB"))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for n := range eventBufferSize * 4 {
+			c.handleMessage("192.168.1.23", statusPacket(1, n%101))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener blocked on slow subscriber")
+	}
+}
+
+func TestSubscribeClosedOnCancel(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	defer c.cancel()
+	ctx, cancel := context.WithCancel(context.Background())
+	events := c.Subscribe(ctx, nil)
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("subscription not closed")
+	}
+}
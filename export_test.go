@@ -0,0 +1,12 @@
+package govee
+
+// PollSchedule exposes pollSchedule to the external tests.
+var PollSchedule = pollSchedule
+
+// StatusWaiters returns how many RequestStatus callers are waiting for d
+// to report its status.
+func StatusWaiters(d *Device) int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.statusWaiters)
+}
@@ -0,0 +1,255 @@
+package govee
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// FadeTarget is the end state of a fade. Nil fields are left unchanged.
+// If both Color and ColorKelvin are set the device shows the color
+// temperature, as with the colorwc command.
+type FadeTarget struct {
+	Brightness  *Brightness
+	Color       *Color
+	ColorKelvin *ColorKelvin
+}
+
+// Easing interpolates between from and to, both in the range [0, 1], at
+// progress t in the range [0, 1].
+type Easing func(from, to, t float64) float64
+
+// EaseLinear changes values at a constant rate.
+func EaseLinear(from, to, t float64) float64 {
+	return from + (to-from)*t
+}
+
+// EaseInOut starts and ends slowly and is fastest half way through.
+func EaseInOut(from, to, t float64) float64 {
+	if t < 0.5 {
+		t = 2 * t * t
+	} else {
+		t = 1 - math.Pow(-2*t+2, 2)/2
+	}
+	return from + (to-from)*t
+}
+
+// EasePerceptual changes values at a constant rate of perceived lightness
+// (CIE L*), so fades look even to the eye instead of rushing through the
+// dim end.
+func EasePerceptual(from, to, t float64) float64 {
+	l := EaseLinear(lightness(from), lightness(to), t)
+	return luminance(l)
+}
+
+// lightness converts relative luminance to CIE L*, both in [0, 1].
+func lightness(y float64) float64 {
+	if y <= 216.0/24389.0 {
+		return y * 24389.0 / 27.0 / 100
+	}
+	return (116*math.Cbrt(y) - 16) / 100
+}
+
+// luminance converts CIE L* to relative luminance, both in [0, 1].
+func luminance(l float64) float64 {
+	l *= 100
+	if l <= 8 {
+		return l * 27.0 / 24389.0
+	}
+	return math.Pow((l+16)/116, 3)
+}
+
+// Color temperatures are interpolated in mireds (reciprocal megakelvin),
+// in which equal steps look like equal changes.
+const (
+	minMired = 1e6 / 9000
+	maxMired = 1e6 / 2000
+)
+
+// kelvinToUnit maps a color temperature to [0, 1] in mireds.
+func kelvinToUnit(k ColorKelvin) float64 {
+	return (1e6/float64(NewColorKelvin(uint(k))) - minMired) / (maxMired - minMired)
+}
+
+// unitToKelvin is the inverse of kelvinToUnit.
+func unitToKelvin(u float64) ColorKelvin {
+	return NewColorKelvin(uint(math.Round(1e6 / (minMired + u*(maxMired-minMired)))))
+}
+
+// unitToChannel maps [0, 1] to a color channel value.
+func unitToChannel(u float64) uint {
+	return uint(math.Round(math.Max(0, math.Min(1, u)) * 255))
+}
+
+// fadeFrame computes the state of a fade at progress t.
+func fadeFrame(from DeviceState, target FadeTarget, t float64, easing Easing) DeviceState {
+	frame := from
+	if target.Brightness != nil {
+		b := easing(float64(from.Brightness)/100, float64(*target.Brightness)/100, t)
+		frame.Brightness = NewBrightness(uint(math.Round(math.Max(0, b) * 100)))
+	}
+	if target.Color != nil {
+		frame.Color = Color{
+			R: unitToChannel(easing(float64(from.Color.R)/255, float64(target.Color.R)/255, t)),
+			G: unitToChannel(easing(float64(from.Color.G)/255, float64(target.Color.G)/255, t)),
+			B: unitToChannel(easing(float64(from.Color.B)/255, float64(target.Color.B)/255, t)),
+		}
+	}
+	if target.ColorKelvin != nil {
+		if from.ColorKelvin == 0 {
+			// The device was showing a color, there is no
+			// temperature to fade from.
+			frame.ColorKelvin = *target.ColorKelvin
+		} else {
+			frame.ColorKelvin = unitToKelvin(easing(kelvinToUnit(from.ColorKelvin), kelvinToUnit(*target.ColorKelvin), t))
+		}
+	}
+	return frame
+}
+
+// FadeTo gradually changes the device from its last known state to target
+// over duration, sending intermediate brightness and colorwc commands at
+// the controller's fade frame rate. Easing shapes the transition; nil
+// means EaseLinear.
+//
+// FadeTo blocks until the fade completes. Starting another fade or
+// sending any other command to the device cancels it, in which case
+// ErrFadeCanceled is returned. If ctx expires first, ctx.Err() is
+// returned.
+func (d *Device) FadeTo(ctx context.Context, target FadeTarget, duration time.Duration, easing Easing) error {
+	if easing == nil {
+		easing = EaseLinear
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	f := d.startFade(cancel)
+	defer d.endFade(f)
+
+	from := d.Snapshot()
+	frames := max(1, int(duration.Seconds()*float64(d.controller.config.fadeFrameRate)))
+	d.logger.Debug("Starting fade", "duration", duration, "frames", frames)
+
+	var tick <-chan time.Time
+	if duration > 0 {
+		ticker := time.NewTicker(duration / time.Duration(frames))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	last := from
+	for i := 1; i <= frames; i++ {
+		if tick != nil {
+			select {
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			case <-tick:
+			}
+		}
+
+		frame := fadeFrame(from, target, float64(i)/float64(frames), easing)
+		if i == frames {
+			frame = fadeFrame(from, target, 1, EaseLinear)
+		}
+		if err := d.sendFrame(ctx, f, last, frame, target, i == frames); err != nil {
+			if cause := context.Cause(ctx); cause != nil {
+				return cause
+			}
+			return err
+		}
+		last = frame
+	}
+	return nil
+}
+
+// sendFrame sends the commands needed to move the device from last to
+// frame. Unchanged properties are skipped except on the final frame.
+func (d *Device) sendFrame(ctx context.Context, f *fade, last, frame DeviceState, target FadeTarget, final bool) error {
+	if target.Brightness != nil && (final || frame.Brightness != last.Brightness) {
+		if err := d.doFrame(ctx, f, BrightnessCommand(frame.Brightness)); err != nil {
+			return err
+		}
+	}
+	if target.Color == nil && target.ColorKelvin == nil {
+		return nil
+	}
+	if !final && frame.Color == last.Color && frame.ColorKelvin == last.ColorKelvin {
+		return nil
+	}
+	data := colorRequest{}
+	if target.Color != nil {
+		data.Color = frame.Color
+	}
+	if target.ColorKelvin != nil {
+		data.Kelvin = frame.ColorKelvin
+	}
+	return d.doFrame(ctx, f, Command{cmd: "colorwc", data: data})
+}
+
+// doFrame sends cmd as a frame of the fade f like do. The frame is queued
+// with f.mu held and only if ctx is still live, so no frame is queued
+// once the fade has been stopped.
+func (d *Device) doFrame(ctx context.Context, f *fade, cmd Command) error {
+	d.logger.Debug("Sending fade frame", "cmd", cmd)
+	msg, err := cmd.message(d.IP())
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	if err := ctx.Err(); err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	result, err := d.controller.queue(ctx, msg)
+	f.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return d.controller.await(ctx, result)
+}
+
+// fade is a fade in progress on a device.
+type fade struct {
+	cancel context.CancelCauseFunc
+	// mu is held while a frame is queued.
+	mu sync.Mutex
+}
+
+// stop cancels the fade and waits for a frame being queued, so that no
+// frame of the fade is queued after it returns. Canceling first unblocks
+// a frame waiting for room in the queue.
+func (f *fade) stop(cause error) {
+	f.cancel(cause)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+}
+
+// startFade registers a new fade, canceling any earlier fade.
+func (d *Device) startFade(cancel context.CancelCauseFunc) *fade {
+	d.fadeMu.Lock()
+	defer d.fadeMu.Unlock()
+	if d.fade != nil {
+		d.fade.stop(ErrFadeCanceled)
+	}
+	d.fade = &fade{cancel: cancel}
+	return d.fade
+}
+
+// endFade unregisters f if it is still the fade in progress.
+func (d *Device) endFade(f *fade) {
+	d.fadeMu.Lock()
+	defer d.fadeMu.Unlock()
+	if d.fade == f {
+		d.fade = nil
+	}
+}
+
+// cancelFade stops the fade in progress, if any.
+func (d *Device) cancelFade() {
+	d.fadeMu.Lock()
+	defer d.fadeMu.Unlock()
+	if d.fade != nil {
+		d.fade.stop(ErrFadeCanceled)
+		d.fade = nil
+	}
+}
@@ -0,0 +1,162 @@
+package govee_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+	"github.com/swrm-io/go-vee/goveetest"
+)
+
+// sentValue decodes the body of a sent message into v.
+func sentValue(t *testing.T, p goveetest.Packet, v any) {
+	t.Helper()
+	require.NoError(t, json.Unmarshal(p.Msg.MSG.Data, v))
+}
+
+func TestEasing(t *testing.T) {
+	easings := map[string]govee.Easing{
+		"linear":     govee.EaseLinear,
+		"in-out":     govee.EaseInOut,
+		"perceptual": govee.EasePerceptual,
+	}
+	for name, easing := range easings {
+		t.Run(name, func(t *testing.T) {
+			assert.InDelta(t, 0.2, easing(0.2, 0.8, 0), 1e-9)
+			assert.InDelta(t, 0.8, easing(0.2, 0.8, 1), 1e-9)
+			assert.InDelta(t, 0.8, easing(0.8, 0.2, 0), 1e-9)
+			assert.InDelta(t, 0.2, easing(0.8, 0.2, 1), 1e-9)
+
+			prev := easing(0, 1, 0)
+			for i := 1; i <= 10; i++ {
+				v := easing(0, 1, float64(i)/10)
+				assert.GreaterOrEqual(t, v, prev, "not monotonic at %d", i)
+				prev = v
+			}
+		})
+	}
+
+	assert.InDelta(t, 0.5, govee.EaseLinear(0, 1, 0.5), 1e-9)
+	assert.InDelta(t, 0.5, govee.EaseInOut(0, 1, 0.5), 1e-9)
+	assert.Less(t, govee.EaseInOut(0, 1, 0.1), govee.EaseLinear(0, 1, 0.1))
+	// Half way in perceived lightness is well under half the luminance.
+	assert.InDelta(t, 0.184, govee.EasePerceptual(0, 1, 0.5), 0.01)
+}
+
+func TestFadeToBrightness(t *testing.T) {
+	transport, device := startWithTransport(t, govee.DeviceState{State: 1, Brightness: 0}, govee.WithFadeFrameRate(50))
+
+	target := govee.NewBrightness(100)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- device.FadeTo(context.Background(), govee.FadeTarget{Brightness: &target}, 200*time.Millisecond, govee.EaseLinear)
+	}()
+
+	var values []govee.Brightness
+	for {
+		p := <-transport.Sent()
+		require.Equal(t, "brightness", p.Msg.MSG.CMD)
+		var body struct{ Value govee.Brightness }
+		sentValue(t, p, &body)
+		values = append(values, body.Value)
+		if body.Value == 100 {
+			break
+		}
+	}
+	require.NoError(t, <-errc)
+
+	assert.Len(t, values, 10)
+	assert.IsIncreasing(t, values)
+	assert.Equal(t, govee.Brightness(10), values[0])
+}
+
+func TestFadeToColorAndKelvin(t *testing.T) {
+	transport, device := startWithTransport(t, govee.DeviceState{State: 1, Brightness: 50, ColorKelvin: 2000}, govee.WithFadeFrameRate(10))
+
+	kelvin := govee.NewColorKelvin(6500)
+	require.NoError(t, device.FadeTo(context.Background(), govee.FadeTarget{ColorKelvin: &kelvin}, 300*time.Millisecond, govee.EaseInOut))
+
+	var values []govee.ColorKelvin
+	for len(values) < 3 {
+		p := <-transport.Sent()
+		require.Equal(t, "colorwc", p.Msg.MSG.CMD)
+		var body struct {
+			Kelvin govee.ColorKelvin `json:"colorTemInKelvin"`
+		}
+		sentValue(t, p, &body)
+		values = append(values, body.Kelvin)
+	}
+	assert.IsIncreasing(t, values)
+	assert.Equal(t, govee.ColorKelvin(6500), values[2])
+
+	red := govee.NewColor(255, 0, 0)
+	require.NoError(t, device.FadeTo(context.Background(), govee.FadeTarget{Color: &red}, 0, nil))
+	p := <-transport.Sent()
+	var body struct {
+		Color  govee.Color
+		Kelvin govee.ColorKelvin `json:"colorTemInKelvin"`
+	}
+	sentValue(t, p, &body)
+	assert.Equal(t, red, body.Color)
+	assert.Zero(t, body.Kelvin)
+}
+
+func TestFadeToCanceledByCommand(t *testing.T) {
+	transport, device := startWithTransport(t, govee.DeviceState{State: 1, Brightness: 0})
+	go func() {
+		for range transport.Sent() {
+		}
+	}()
+
+	target := govee.NewBrightness(100)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- device.FadeTo(context.Background(), govee.FadeTarget{Brightness: &target}, 10*time.Second, nil)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, device.Do(context.Background(), govee.TurnOffCommand()))
+	select {
+	case err := <-errc:
+		assert.ErrorIs(t, err, govee.ErrFadeCanceled)
+	case <-time.After(time.Second):
+		t.Fatal("fade not canceled")
+	}
+}
+
+func TestFadeToCanceledByFade(t *testing.T) {
+	transport, device := startWithTransport(t, govee.DeviceState{State: 1, Brightness: 0})
+	go func() {
+		for range transport.Sent() {
+		}
+	}()
+
+	target := govee.NewBrightness(100)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- device.FadeTo(context.Background(), govee.FadeTarget{Brightness: &target}, 10*time.Second, nil)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	dim := govee.NewBrightness(10)
+	require.NoError(t, device.FadeTo(context.Background(), govee.FadeTarget{Brightness: &dim}, 50*time.Millisecond, nil))
+	assert.ErrorIs(t, <-errc, govee.ErrFadeCanceled)
+}
+
+func TestFadeToContextExpired(t *testing.T) {
+	transport, device := startWithTransport(t, govee.DeviceState{State: 1, Brightness: 0})
+	go func() {
+		for range transport.Sent() {
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	target := govee.NewBrightness(100)
+	err := device.FadeTo(ctx, govee.FadeTarget{Brightness: &target}, 10*time.Second, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
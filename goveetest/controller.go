@@ -0,0 +1,47 @@
+package goveetest
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	govee "github.com/swrm-io/go-vee"
+)
+
+// startTimeout bounds how long StartController waits for the controller
+// to start.
+const startTimeout = 5 * time.Second
+
+// StartController starts a controller with opts for the duration of a
+// test. It fails the test, with Start's error if there is one, if the
+// controller does not start, and shuts it down when the test ends. Pass
+// govee.WithTransport to run it over a Transport, or the ports and
+// address of a simulated Device.
+func StartController(tb testing.TB, opts ...govee.Option) *govee.Controller {
+	tb.Helper()
+	c := govee.NewController(slog.New(slog.DiscardHandler), opts...)
+	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+	defer cancel()
+
+	// Start returns early only if it failed, so stop waiting for Ready
+	// and report its error.
+	failed := make(chan error, 1)
+	go func() {
+		if err := c.Start(); err != nil {
+			failed <- err
+			cancel()
+		}
+	}()
+	tb.Cleanup(func() { _ = c.Shutdown() })
+
+	if err := c.Ready(ctx); err != nil {
+		select {
+		case err := <-failed:
+			tb.Fatalf("controller failed to start: %v", err)
+		default:
+			tb.Fatalf("controller did not start: %v", err)
+		}
+	}
+	return c
+}
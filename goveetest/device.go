@@ -0,0 +1,372 @@
+// Package goveetest provides a simulated Govee device for testing code
+// built on the govee Controller without real lights on the network.
+//
+// A simulated Device binds to a loopback address and speaks the Govee LAN
+// API JSON protocol: it answers scan requests on the scan port and handles
+// turn, brightness, colorwc and devStatus on the command port, replying to
+// the sender on the listen port. Point a Controller at it with:
+//
+//	ports, _ := goveetest.FreePorts()
+//	dev, _ := goveetest.NewDevice("127.0.0.2", goveetest.WithPorts(ports))
+//	defer dev.Close()
+//	c := govee.NewController(logger,
+//		govee.WithMulticastAddress(dev.IP()),
+//		govee.WithPorts(ports.Scan, ports.Listen, ports.Command),
+//	)
+package goveetest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	govee "github.com/swrm-io/go-vee"
+)
+
+// queueSize is the number of received packets that may be waiting to be
+// processed before further packets are dropped.
+const queueSize = 64
+
+// Ports holds the UDP ports used by the Govee LAN API.
+type Ports struct {
+	Scan    int
+	Listen  int
+	Command int
+}
+
+// DefaultPorts are the ports used by real Govee devices.
+var DefaultPorts = Ports{
+	Scan:    govee.DefaultScanPort,
+	Listen:  govee.DefaultListenPort,
+	Command: govee.DefaultCommandPort,
+}
+
+// FreePorts returns a set of UDP ports that are currently unused on the
+// loopback interface.
+func FreePorts() (Ports, error) {
+	var ports [3]int
+	for i := range ports {
+		conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			return Ports{}, err
+		}
+		// Keep every socket open until all ports are chosen so the
+		// same port is not returned twice.
+		defer conn.Close()
+		ports[i] = conn.LocalAddr().(*net.UDPAddr).Port
+	}
+	return Ports{Scan: ports[0], Listen: ports[1], Command: ports[2]}, nil
+}
+
+// Device is a simulated Govee device.
+type Device struct {
+	ip              string
+	deviceID        string
+	sku             string
+	bleVersionHard  govee.Version
+	bleVersionSoft  govee.Version
+	wifiVersionHard govee.Version
+	wifiVersionSoft govee.Version
+	ports           Ports
+	latency         time.Duration
+	packetLoss      float64
+	malformed       float64
+
+	mu       sync.Mutex
+	state    govee.DeviceState
+	commands []string
+
+	scanConn    *net.UDPConn
+	commandConn *net.UDPConn
+	packets     chan packet
+	done        chan struct{}
+	wg          sync.WaitGroup
+	closeOnce   sync.Once
+}
+
+// packet is a request received by the device.
+type packet struct {
+	at   time.Time
+	src  *net.UDPAddr
+	data []byte
+	conn *net.UDPConn
+}
+
+// Option configures a simulated Device.
+type Option func(*Device)
+
+// WithDeviceID sets the device ID reported in scan responses.
+func WithDeviceID(id string) Option {
+	return func(d *Device) {
+		d.deviceID = id
+	}
+}
+
+// WithSKU sets the SKU reported in scan responses.
+func WithSKU(sku string) Option {
+	return func(d *Device) {
+		d.sku = sku
+	}
+}
+
+// WithVersions sets the BLE and WiFi hardware and software versions
+// reported in scan responses.
+func WithVersions(bleHard, bleSoft, wifiHard, wifiSoft govee.Version) Option {
+	return func(d *Device) {
+		d.bleVersionHard = bleHard
+		d.bleVersionSoft = bleSoft
+		d.wifiVersionHard = wifiHard
+		d.wifiVersionSoft = wifiSoft
+	}
+}
+
+// WithPorts sets the ports the device listens and replies on.
+func WithPorts(ports Ports) Option {
+	return func(d *Device) {
+		d.ports = ports
+	}
+}
+
+// WithLatency delays the handling of every request by latency.
+func WithLatency(latency time.Duration) Option {
+	return func(d *Device) {
+		d.latency = latency
+	}
+}
+
+// WithPacketLoss drops each received request with the given probability
+// in the range [0, 1].
+func WithPacketLoss(probability float64) Option {
+	return func(d *Device) {
+		d.packetLoss = probability
+	}
+}
+
+// WithMalformedReplies replaces each reply with invalid JSON with the
+// given probability in the range [0, 1].
+func WithMalformedReplies(probability float64) Option {
+	return func(d *Device) {
+		d.malformed = probability
+	}
+}
+
+// WithState sets the initial state of the device.
+func WithState(state govee.DeviceState) Option {
+	return func(d *Device) {
+		d.state = state
+	}
+}
+
+// NewDevice starts a simulated device bound to ip, which should be a
+// loopback address such as 127.0.0.2. The device runs until Close is
+// called.
+func NewDevice(ip string, opts ...Option) (*Device, error) {
+	d := &Device{
+		ip:              ip,
+		deviceID:        "00:00:00:00:00:00:00:01",
+		sku:             "H6159",
+		bleVersionHard:  defaultVersions.BleVersionHard,
+		bleVersionSoft:  defaultVersions.BleVersionSoft,
+		wifiVersionHard: defaultVersions.WifiVersionHard,
+		wifiVersionSoft: defaultVersions.WifiVersionSoft,
+		ports:           DefaultPorts,
+		packets:         make(chan packet, queueSize),
+		done:            make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil, fmt.Errorf("invalid device address %q", ip)
+	}
+
+	var err error
+	d.scanConn, err = net.ListenUDP("udp4", &net.UDPAddr{IP: addr, Port: d.ports.Scan})
+	if err != nil {
+		return nil, err
+	}
+	d.commandConn, err = net.ListenUDP("udp4", &net.UDPAddr{IP: addr, Port: d.ports.Command})
+	if err != nil {
+		d.scanConn.Close()
+		return nil, err
+	}
+
+	d.wg.Add(3)
+	go d.read(d.scanConn)
+	go d.read(d.commandConn)
+	go d.process()
+	return d, nil
+}
+
+// Close stops the device and releases its sockets.
+func (d *Device) Close() error {
+	var err error
+	d.closeOnce.Do(func() {
+		close(d.done)
+		err = errors.Join(d.scanConn.Close(), d.commandConn.Close())
+		d.wg.Wait()
+	})
+	return err
+}
+
+// IP returns the address the device is bound to.
+func (d *Device) IP() string { return d.ip }
+
+// DeviceID returns the device ID reported in scan responses.
+func (d *Device) DeviceID() string { return d.deviceID }
+
+// State returns the current simulated state of the device.
+func (d *Device) State() govee.DeviceState {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.state
+}
+
+// SetState changes the simulated state of the device, as if it had been
+// changed with the Govee app.
+func (d *Device) SetState(state govee.DeviceState) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.state = state
+}
+
+// Commands returns the names of the commands handled so far, in order.
+func (d *Device) Commands() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.commands...)
+}
+
+// read receives packets from conn and queues them for processing until
+// conn is closed.
+func (d *Device) read(conn *net.UDPConn) {
+	defer d.wg.Done()
+	for {
+		buffer := make([]byte, govee.DefaultReadBuffer)
+		n, src, err := conn.ReadFromUDP(buffer)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		if rand.Float64() < d.packetLoss {
+			continue
+		}
+		select {
+		case d.packets <- packet{at: time.Now(), src: src, data: buffer[:n], conn: conn}:
+		default:
+		}
+	}
+}
+
+// process handles queued packets in order, applying the configured latency.
+func (d *Device) process() {
+	defer d.wg.Done()
+	for {
+		select {
+		case p := <-d.packets:
+			if wait := time.Until(p.at.Add(d.latency)); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-d.done:
+					return
+				}
+			}
+			d.handle(p)
+		case <-d.done:
+			return
+		}
+	}
+}
+
+// handle applies a request to the simulated state and sends any reply.
+func (d *Device) handle(p packet) {
+	var request govee.Wrapper
+	if err := json.Unmarshal(p.data, &request); err != nil {
+		return
+	}
+
+	d.mu.Lock()
+	d.commands = append(d.commands, request.MSG.CMD)
+	d.mu.Unlock()
+
+	switch {
+	case request.MSG.CMD == "scan" && p.conn == d.scanConn:
+		d.reply(p.src, "scan", scanResponse{
+			IP:              d.ip,
+			DeviceID:        d.deviceID,
+			SKU:             d.sku,
+			BleVersionHard:  d.bleVersionHard,
+			BleVersionSoft:  d.bleVersionSoft,
+			WifiVersionHard: d.wifiVersionHard,
+			WifiVersionSoft: d.wifiVersionSoft,
+		})
+
+	case request.MSG.CMD == "turn" && p.conn == d.commandConn:
+		var data valueRequest
+		if json.Unmarshal(request.MSG.Data, &data) == nil {
+			d.mu.Lock()
+			d.state.State = govee.State(data.Value)
+			d.mu.Unlock()
+		}
+
+	case request.MSG.CMD == "brightness" && p.conn == d.commandConn:
+		var data valueRequest
+		if json.Unmarshal(request.MSG.Data, &data) == nil {
+			d.mu.Lock()
+			d.state.Brightness = govee.NewBrightness(data.Value)
+			d.mu.Unlock()
+		}
+
+	case request.MSG.CMD == "colorwc" && p.conn == d.commandConn:
+		var data colorRequest
+		if json.Unmarshal(request.MSG.Data, &data) == nil {
+			d.mu.Lock()
+			d.state.Color = data.Color
+			d.state.ColorKelvin = data.Kelvin
+			d.mu.Unlock()
+		}
+
+	case request.MSG.CMD == "devStatus" && p.conn == d.commandConn:
+		state := d.State()
+		d.reply(p.src, "devStatus", devStatusResponse{
+			OnOff:       state.State,
+			Brightness:  state.Brightness,
+			Color:       state.Color,
+			ColorKelvin: state.ColorKelvin,
+		})
+	}
+}
+
+// reply sends a response to the listen port of the host that sent a request.
+func (d *Device) reply(to *net.UDPAddr, cmd string, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	var response govee.Wrapper
+	response.MSG.CMD = cmd
+	response.MSG.Data = payload
+	out, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	if rand.Float64() < d.malformed {
+		out = out[:len(out)/2]
+	}
+
+	addr := net.JoinHostPort(to.IP.String(), strconv.Itoa(d.ports.Listen))
+	dst, err := net.ResolveUDPAddr("udp4", addr)
+	if err != nil {
+		return
+	}
+	_, _ = d.commandConn.WriteToUDP(out, dst)
+}
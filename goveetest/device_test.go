@@ -0,0 +1,127 @@
+package goveetest_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+	"github.com/swrm-io/go-vee/goveetest"
+)
+
+// newDevice starts a simulated device on free ports and closes it when the
+// test finishes.
+func newDevice(t *testing.T, ip string, opts ...goveetest.Option) (*goveetest.Device, goveetest.Ports) {
+	t.Helper()
+	ports, err := goveetest.FreePorts()
+	require.NoError(t, err)
+	dev, err := goveetest.NewDevice(ip, append([]goveetest.Option{goveetest.WithPorts(ports)}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, dev.Close()) })
+	return dev, ports
+}
+
+// exchange sends a raw request to the device and returns the raw reply, or
+// nil if none arrives.
+func exchange(t *testing.T, ports goveetest.Ports, to string, port int, request string) []byte {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: ports.Listen})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.WriteToUDP([]byte(request), &net.UDPAddr{IP: net.ParseIP(to), Port: port})
+	require.NoError(t, err)
+
+	buffer := make([]byte, 1024)
+	_ = conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	n, _, err := conn.ReadFromUDP(buffer)
+	if err != nil {
+		return nil
+	}
+	return buffer[:n]
+}
+
+func TestDeviceScan(t *testing.T) {
+	dev, ports := newDevice(t, "127.0.0.2",
+		goveetest.WithDeviceID("AA:BB:CC:DD:EE:FF:00:11"),
+		goveetest.WithSKU("H619A"),
+		goveetest.WithVersions(govee.NewVersion(1, 0, 0), govee.NewVersion(1, 0, 1), govee.NewVersion(2, 0, 0), govee.NewVersion(2, 0, 1)),
+	)
+
+	reply := exchange(t, ports, dev.IP(), ports.Scan, `{"msg":{"cmd":"scan","data":{"account_topic":"reserve"}}}`)
+	assert.JSONEq(t, `{"msg":{"cmd":"scan","data":{"ip":"127.0.0.2","device":"AA:BB:CC:DD:EE:FF:00:11","sku":"H619A","bleVersionHard":"1.0.0","bleVersionSoft":"1.0.1","wifiVersionHard":"2.0.0","wifiVersionSoft":"2.0.1"}}}`, string(reply))
+}
+
+func TestDeviceCommands(t *testing.T) {
+	dev, ports := newDevice(t, "127.0.0.2")
+
+	for _, request := range []string{
+		`{"msg":{"cmd":"turn","data":{"value":1}}}`,
+		`{"msg":{"cmd":"brightness","data":{"value":40}}}`,
+		`{"msg":{"cmd":"colorwc","data":{"color":{"r":0,"g":0,"b":0},"colorTemInKelvin":3000}}}`,
+	} {
+		assert.Nil(t, exchange(t, ports, dev.IP(), ports.Command, request))
+	}
+
+	reply := exchange(t, ports, dev.IP(), ports.Command, `{"msg":{"cmd":"devStatus","data":{}}}`)
+	assert.JSONEq(t, `{"msg":{"cmd":"devStatus","data":{"onOff":1,"brightness":40,"color":{"r":0,"g":0,"b":0},"colorTemInKelvin":3000}}}`, string(reply))
+	assert.Equal(t, []string{"turn", "brightness", "colorwc", "devStatus"}, dev.Commands())
+}
+
+func TestDevicePacketLoss(t *testing.T) {
+	dev, ports := newDevice(t, "127.0.0.2", goveetest.WithPacketLoss(1))
+
+	reply := exchange(t, ports, dev.IP(), ports.Command, `{"msg":{"cmd":"devStatus","data":{}}}`)
+	assert.Nil(t, reply)
+	assert.Empty(t, dev.Commands())
+}
+
+func TestDeviceMalformedReplies(t *testing.T) {
+	dev, ports := newDevice(t, "127.0.0.2", goveetest.WithMalformedReplies(1))
+
+	reply := exchange(t, ports, dev.IP(), ports.Command, `{"msg":{"cmd":"devStatus","data":{}}}`)
+	require.NotNil(t, reply)
+	assert.False(t, json.Valid(reply))
+}
+
+func TestDeviceLatency(t *testing.T) {
+	dev, ports := newDevice(t, "127.0.0.2", goveetest.WithLatency(100*time.Millisecond))
+
+	start := time.Now()
+	reply := exchange(t, ports, dev.IP(), ports.Command, `{"msg":{"cmd":"devStatus","data":{}}}`)
+	require.NotNil(t, reply)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestDeviceWithController(t *testing.T) {
+	dev, ports := newDevice(t, "127.0.0.2",
+		goveetest.WithDeviceID("AA:BB:CC:DD:EE:FF:00:11"),
+		goveetest.WithState(govee.DeviceState{State: 1, Brightness: 10}),
+	)
+
+	c := goveetest.StartController(t,
+		govee.WithMulticastAddress(dev.IP()),
+		govee.WithPorts(ports.Scan, ports.Listen, ports.Command),
+	)
+
+	var device *govee.Device
+	require.Eventually(t, func() bool {
+		device, _ = c.DeviceByID("AA:BB:CC:DD:EE:FF:00:11")
+		return device != nil
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "H6159", device.SKU())
+	assert.Equal(t, "127.0.0.2", device.IP())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, device.Do(ctx, govee.BrightnessCommand(75)))
+	state, err := device.RequestStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, govee.Brightness(75), state.Brightness)
+	assert.Equal(t, govee.Brightness(75), device.Brightness())
+	assert.Equal(t, govee.Brightness(75), dev.State().Brightness)
+}
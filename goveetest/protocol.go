@@ -0,0 +1,42 @@
+package goveetest
+
+import govee "github.com/swrm-io/go-vee"
+
+// scanResponse is the reply to a scan request.
+type scanResponse struct {
+	IP              string        `json:"ip"`
+	DeviceID        string        `json:"device"`
+	SKU             string        `json:"sku"`
+	BleVersionHard  govee.Version `json:"bleVersionHard"`
+	BleVersionSoft  govee.Version `json:"bleVersionSoft"`
+	WifiVersionHard govee.Version `json:"wifiVersionHard"`
+	WifiVersionSoft govee.Version `json:"wifiVersionSoft"`
+}
+
+// defaultVersions holds the firmware versions reported by simulated
+// devices unless WithVersions is given.
+var defaultVersions = scanResponse{
+	BleVersionHard:  govee.NewVersion(3, 1, 1),
+	BleVersionSoft:  govee.NewVersion(1, 3, 1),
+	WifiVersionHard: govee.NewVersion(1, 0, 10),
+	WifiVersionSoft: govee.NewVersion(1, 2, 3),
+}
+
+// valueRequest is the body of the turn and brightness requests.
+type valueRequest struct {
+	Value uint `json:"value"`
+}
+
+// colorRequest is the body of the colorwc request.
+type colorRequest struct {
+	Color  govee.Color       `json:"color"`
+	Kelvin govee.ColorKelvin `json:"colorTemInKelvin"`
+}
+
+// devStatusResponse is the reply to a devStatus request.
+type devStatusResponse struct {
+	OnOff       govee.State       `json:"onOff"`
+	Brightness  govee.Brightness  `json:"brightness"`
+	Color       govee.Color       `json:"color"`
+	ColorKelvin govee.ColorKelvin `json:"colorTemInKelvin"`
+}
@@ -0,0 +1,129 @@
+package goveetest
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+
+	govee "github.com/swrm-io/go-vee"
+)
+
+// Packet is a message sent through a Transport.
+type Packet struct {
+	// Addr is the "host:port" destination of a sent message, or the
+	// source IP address of a delivered message.
+	Addr string
+	Msg  *govee.Wrapper
+}
+
+// Transport is an in-memory govee.Transport. Messages sent by the
+// controller are available from Sent, and Deliver injects messages as if
+// they had been received from a device.
+type Transport struct {
+	incoming chan Packet
+	sent     chan Packet
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// NewTransport returns an in-memory transport for use with
+// govee.WithTransport.
+func NewTransport() *Transport {
+	return &Transport{
+		incoming: make(chan Packet, queueSize),
+		sent:     make(chan Packet, queueSize),
+		closed:   make(chan struct{}),
+	}
+}
+
+// Send records msg as sent to addr. It blocks while the Sent buffer is
+// full.
+func (t *Transport) Send(addr string, msg *govee.Wrapper) error {
+	if t.isClosed() {
+		return net.ErrClosed
+	}
+	select {
+	case t.sent <- Packet{Addr: addr, Msg: msg}:
+		return nil
+	case <-t.closed:
+		return net.ErrClosed
+	}
+}
+
+// Receive returns the next delivered message.
+func (t *Transport) Receive(ctx context.Context) (string, *govee.Wrapper, error) {
+	select {
+	case p := <-t.incoming:
+		return p.Addr, p.Msg, nil
+	case <-ctx.Done():
+		return "", nil, ctx.Err()
+	case <-t.closed:
+		return "", nil, net.ErrClosed
+	}
+}
+
+// Close stops the transport.
+func (t *Transport) Close() error {
+	t.closeOnce.Do(func() { close(t.closed) })
+	return nil
+}
+
+// isClosed reports whether Close has been called.
+func (t *Transport) isClosed() bool {
+	select {
+	case <-t.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Sent returns the messages sent by the controller, in order.
+func (t *Transport) Sent() <-chan Packet {
+	return t.sent
+}
+
+// Deliver queues a message from the device at ip for the controller. data
+// is marshaled as the message body. It blocks while the receive buffer is
+// full and returns net.ErrClosed once the transport is closed.
+func (t *Transport) Deliver(ip, cmd string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	msg := &govee.Wrapper{}
+	msg.MSG.CMD = cmd
+	msg.MSG.Data = payload
+
+	if t.isClosed() {
+		return net.ErrClosed
+	}
+	select {
+	case t.incoming <- Packet{Addr: ip, Msg: msg}:
+		return nil
+	case <-t.closed:
+		return net.ErrClosed
+	}
+}
+
+// Announce delivers a scan response from the device id at ip, with the
+// SKU sku and the firmware versions of a simulated Device, as if it had
+// answered a scan.
+func (t *Transport) Announce(ip, id, sku string) error {
+	response := defaultVersions
+	response.IP, response.DeviceID, response.SKU = ip, id, sku
+	return t.Deliver(ip, "scan", response)
+}
+
+// DeliverStatus delivers a devStatus response reporting state from the
+// device at ip.
+func (t *Transport) DeliverStatus(ip string, state govee.DeviceState) error {
+	return t.Deliver(ip, "devStatus", devStatusResponse{
+		OnOff:       state.State,
+		Brightness:  state.Brightness,
+		Color:       state.Color,
+		ColorKelvin: state.ColorKelvin,
+	})
+}
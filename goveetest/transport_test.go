@@ -0,0 +1,66 @@
+package goveetest_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+	"github.com/swrm-io/go-vee/goveetest"
+)
+
+// nextSent waits for the next message sent through the transport.
+func nextSent(t *testing.T, transport *goveetest.Transport) goveetest.Packet {
+	t.Helper()
+	select {
+	case p := <-transport.Sent():
+		return p
+	case <-time.After(time.Second):
+		require.FailNow(t, "timed out waiting for sent message")
+		return goveetest.Packet{}
+	}
+}
+
+func TestTransportWithController(t *testing.T) {
+	transport := goveetest.NewTransport()
+	c := goveetest.StartController(t, govee.WithTransport(transport))
+
+	scan := nextSent(t, transport)
+	assert.Equal(t, "239.255.255.250:4001", scan.Addr)
+	assert.Equal(t, "scan", scan.Msg.MSG.CMD)
+
+	require.NoError(t, transport.Announce("10.0.0.5", "AA:BB", "H6159"))
+	var device *govee.Device
+	require.Eventually(t, func() bool {
+		device, _ = c.DeviceByID("AA:BB")
+		return device != nil && device.SKU() == "H6159"
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, govee.NewVersion(3, 1, 1), device.BleVersionHard())
+
+	require.NoError(t, transport.DeliverStatus("10.0.0.5", govee.DeviceState{State: 1, Brightness: 30}))
+	require.Eventually(t, func() bool {
+		return device.Brightness() == 30
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, device.Do(ctx, govee.TurnOnCommand()))
+
+	turn := nextSent(t, transport)
+	assert.Equal(t, "10.0.0.5:4003", turn.Addr)
+	assert.Equal(t, "turn", turn.Msg.MSG.CMD)
+	assert.JSONEq(t, `{"value":1}`, string(turn.Msg.MSG.Data))
+}
+
+func TestTransportClosed(t *testing.T) {
+	transport := goveetest.NewTransport()
+	require.NoError(t, transport.Close())
+	require.NoError(t, transport.Close())
+
+	_, _, err := transport.Receive(context.Background())
+	assert.Error(t, err)
+	assert.Error(t, transport.Deliver("10.0.0.5", "devStatus", json.RawMessage(`{}`)))
+}
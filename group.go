@@ -0,0 +1,214 @@
+package govee
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+)
+
+// Selector reports whether a device belongs to a Group.
+type Selector func(*Device) bool
+
+// ByDeviceID selects devices with any of the given device IDs.
+func ByDeviceID(ids ...string) Selector {
+	return func(d *Device) bool {
+		return slices.Contains(ids, d.DeviceID())
+	}
+}
+
+// BySKU selects devices with any of the given SKUs.
+func BySKU(skus ...string) Selector {
+	return func(d *Device) bool {
+		return slices.Contains(skus, d.SKU())
+	}
+}
+
+// Group is a named set of devices that can be controlled together, such as
+// a room. Members are chosen by selectors each time a command is sent, so
+// devices discovered later are included automatically. Groups may contain
+// other groups, so a "house" group can be made of "kitchen" and "living
+// room".
+type Group struct {
+	name       string
+	controller *Controller
+
+	mu        sync.RWMutex
+	selectors []Selector
+	groups    []*Group
+}
+
+// NewGroup creates a group of the devices matching any of the selectors
+// and registers it with the controller under name, replacing any group
+// with the same name.
+func (c *Controller) NewGroup(name string, selectors ...Selector) *Group {
+	g := &Group{
+		name:       name,
+		controller: c,
+		selectors:  selectors,
+	}
+	c.groupsMu.Lock()
+	c.groups[name] = g
+	c.groupsMu.Unlock()
+	return g
+}
+
+// Group returns the group registered under name, or an error if not found.
+func (c *Controller) Group(name string) (*Group, error) {
+	c.groupsMu.RLock()
+	defer c.groupsMu.RUnlock()
+	if g, ok := c.groups[name]; ok {
+		return g, nil
+	}
+	return nil, ErrNoGroupFound
+}
+
+// Groups returns all registered groups sorted by name.
+func (c *Controller) Groups() []*Group {
+	c.groupsMu.RLock()
+	defer c.groupsMu.RUnlock()
+	groups := make([]*Group, 0, len(c.groups))
+	for _, g := range c.groups {
+		groups = append(groups, g)
+	}
+	slices.SortFunc(groups, func(a, b *Group) int {
+		return cmp.Compare(a.name, b.name)
+	})
+	return groups
+}
+
+// Name returns the name of the group.
+func (g *Group) Name() string { return g.name }
+
+// Select adds selectors to the group.
+func (g *Group) Select(selectors ...Selector) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.selectors = append(g.selectors, selectors...)
+}
+
+// Add nests groups inside this group.
+func (g *Group) Add(groups ...*Group) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.groups = append(g.groups, groups...)
+}
+
+// Devices returns the devices currently in the group, including those of
+// nested groups. Each device appears once.
+func (g *Group) Devices() []*Device {
+	seen := map[*Device]bool{}
+	var devices []*Device
+	g.collect(map[*Group]bool{}, func(d *Device) {
+		if !seen[d] {
+			seen[d] = true
+			devices = append(devices, d)
+		}
+	})
+	return devices
+}
+
+// collect calls add for every member device, skipping groups already
+// visited so that cyclic nesting terminates.
+func (g *Group) collect(visited map[*Group]bool, add func(*Device)) {
+	if visited[g] {
+		return
+	}
+	visited[g] = true
+
+	g.mu.RLock()
+	selectors := slices.Clone(g.selectors)
+	groups := slices.Clone(g.groups)
+	g.mu.RUnlock()
+
+	for _, d := range g.controller.Devices() {
+		for _, selector := range selectors {
+			if selector(d) {
+				add(d)
+				break
+			}
+		}
+	}
+	for _, sub := range groups {
+		sub.collect(visited, add)
+	}
+}
+
+// each calls fn for every device in the group in parallel and returns the
+// errors keyed by device ID, or by IP address for devices whose ID is not
+// yet known. The map is nil if every call succeeded.
+func (g *Group) each(fn func(*Device) error) map[string]error {
+	var (
+		mu   sync.Mutex
+		errs map[string]error
+		wg   sync.WaitGroup
+	)
+	for _, d := range g.Devices() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(d); err != nil {
+				key := d.DeviceID()
+				if key == "" {
+					key = d.IP()
+				}
+				mu.Lock()
+				if errs == nil {
+					errs = map[string]error{}
+				}
+				errs[key] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// Do sends cmd to every device in the group in parallel. See Device.Do.
+func (g *Group) Do(ctx context.Context, cmd Command) map[string]error {
+	return g.each(func(d *Device) error { return d.Do(ctx, cmd) })
+}
+
+// TurnOn turns every device in the group on.
+func (g *Group) TurnOn(ctx context.Context) map[string]error {
+	return g.Do(ctx, TurnOnCommand())
+}
+
+// TurnOff turns every device in the group off.
+func (g *Group) TurnOff(ctx context.Context) map[string]error {
+	return g.Do(ctx, TurnOffCommand())
+}
+
+// SetBrightness sets the brightness of every device in the group.
+func (g *Group) SetBrightness(ctx context.Context, brightness Brightness) map[string]error {
+	return g.Do(ctx, BrightnessCommand(brightness))
+}
+
+// SetColor sets the color of every device in the group.
+func (g *Group) SetColor(ctx context.Context, color Color) map[string]error {
+	return g.Do(ctx, ColorCommand(color))
+}
+
+// SetColorKelvin sets the color temperature of every device in the group.
+func (g *Group) SetColorKelvin(ctx context.Context, colorKelvin ColorKelvin) map[string]error {
+	return g.Do(ctx, ColorKelvinCommand(colorKelvin))
+}
+
+// Apply applies delta to every device in the group in parallel. See
+// Device.Apply.
+func (g *Group) Apply(ctx context.Context, delta StateDelta) map[string]error {
+	return g.each(func(d *Device) error {
+		_, err := d.Apply(ctx, delta)
+		return err
+	})
+}
+
+// RequestStatus requests the status of every device in the group and
+// waits for the responses or for ctx to expire.
+func (g *Group) RequestStatus(ctx context.Context) map[string]error {
+	return g.each(func(d *Device) error {
+		_, err := d.requestStatus(ctx)
+		return err
+	})
+}
@@ -0,0 +1,124 @@
+package govee_test
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+	"github.com/swrm-io/go-vee/goveetest"
+)
+
+// startHouse starts a controller on an in-memory transport with four
+// devices: two H6159 strips in the kitchen and an H6159 and an H619A in
+// the living room.
+func startHouse(t *testing.T) (*govee.Controller, *goveetest.Transport) {
+	t.Helper()
+	c, transport := startTransport(t)
+	announce(t, c, transport, "10.0.0.1", "kitchen-1", "H6159")
+	announce(t, c, transport, "10.0.0.2", "kitchen-2", "H6159")
+	announce(t, c, transport, "10.0.0.3", "living-1", "H6159")
+	announce(t, c, transport, "10.0.0.4", "living-2", "H619A")
+	return c, transport
+}
+
+// deviceIDs returns the sorted IDs of devices.
+func deviceIDs(devices []*govee.Device) []string {
+	ids := make([]string, 0, len(devices))
+	for _, d := range devices {
+		ids = append(ids, d.DeviceID())
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestGroupSelectors(t *testing.T) {
+	c, _ := startHouse(t)
+
+	byID := c.NewGroup("kitchen", govee.ByDeviceID("kitchen-1", "kitchen-2"))
+	assert.Equal(t, []string{"kitchen-1", "kitchen-2"}, deviceIDs(byID.Devices()))
+
+	bySKU := c.NewGroup("strips", govee.BySKU("H619A"))
+	assert.Equal(t, []string{"living-2"}, deviceIDs(bySKU.Devices()))
+
+	byPredicate := c.NewGroup("living room", func(d *govee.Device) bool {
+		return strings.HasPrefix(d.DeviceID(), "living-")
+	})
+	assert.Equal(t, []string{"living-1", "living-2"}, deviceIDs(byPredicate.Devices()))
+
+	g, err := c.Group("kitchen")
+	require.NoError(t, err)
+	assert.Same(t, byID, g)
+	_, err = c.Group("attic")
+	assert.ErrorIs(t, err, govee.ErrNoGroupFound)
+	assert.Len(t, c.Groups(), 3)
+	assert.Equal(t, "kitchen", c.Groups()[0].Name())
+}
+
+func TestGroupNested(t *testing.T) {
+	c, _ := startHouse(t)
+
+	kitchen := c.NewGroup("kitchen", govee.ByDeviceID("kitchen-1", "kitchen-2"))
+	living := c.NewGroup("living room", govee.ByDeviceID("living-1", "living-2"))
+	house := c.NewGroup("house", govee.BySKU("H6159"))
+	house.Add(kitchen, living)
+	// Cycles must not loop forever.
+	kitchen.Add(house)
+
+	assert.Equal(t, []string{"kitchen-1", "kitchen-2", "living-1", "living-2"}, deviceIDs(house.Devices()))
+}
+
+func TestGroupFanOut(t *testing.T) {
+	c, transport := startHouse(t)
+	kitchen := c.NewGroup("kitchen", govee.ByDeviceID("kitchen-1", "kitchen-2"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Nil(t, kitchen.SetBrightness(ctx, 30))
+
+	var addrs []string
+	for range 2 {
+		p := <-transport.Sent()
+		assert.Equal(t, "brightness", p.Msg.MSG.CMD)
+		addrs = append(addrs, p.Addr)
+	}
+	sort.Strings(addrs)
+	assert.Equal(t, []string{"10.0.0.1:4003", "10.0.0.2:4003"}, addrs)
+}
+
+func TestGroupErrors(t *testing.T) {
+	c, _ := startHouse(t)
+	kitchen := c.NewGroup("kitchen", govee.ByDeviceID("kitchen-1", "kitchen-2"))
+	require.NoError(t, c.Shutdown())
+
+	errs := kitchen.TurnOn(context.Background())
+	require.Len(t, errs, 2)
+	assert.ErrorIs(t, errs["kitchen-1"], govee.ErrNotStarted)
+	assert.ErrorIs(t, errs["kitchen-2"], govee.ErrNotStarted)
+}
+
+func TestGroupApply(t *testing.T) {
+	c, transport := startHouse(t)
+	kitchen := c.NewGroup("kitchen", govee.ByDeviceID("kitchen-1", "kitchen-2"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	on := govee.State(1)
+	brightness := govee.NewBrightness(30)
+	assert.Nil(t, kitchen.Apply(ctx, govee.StateDelta{State: &on, Brightness: &brightness}))
+
+	// Each device gets its brightness before it is turned on.
+	cmds := map[string][]string{}
+	for range 4 {
+		p := <-transport.Sent()
+		cmds[p.Addr] = append(cmds[p.Addr], p.Msg.MSG.CMD)
+	}
+	assert.Equal(t, map[string][]string{
+		"10.0.0.1:4003": {"brightness", "turn"},
+		"10.0.0.2:4003": {"brightness", "turn"},
+	}, cmds)
+}
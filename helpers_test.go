@@ -0,0 +1,87 @@
+package govee_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+	"github.com/swrm-io/go-vee/goveetest"
+)
+
+// startSimulated starts a simulated device at 127.0.0.2 and a controller
+// scanning it, and returns the device once the controller discovered it.
+func startSimulated(t *testing.T, opts ...goveetest.Option) (*govee.Controller, *govee.Device, *goveetest.Device) {
+	t.Helper()
+	ports, err := goveetest.FreePorts()
+	require.NoError(t, err)
+	sim, err := goveetest.NewDevice("127.0.0.2", append([]goveetest.Option{goveetest.WithPorts(ports)}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sim.Close() })
+
+	c := goveetest.StartController(t,
+		govee.WithMulticastAddress(sim.IP()),
+		govee.WithPorts(ports.Scan, ports.Listen, ports.Command),
+	)
+	var device *govee.Device
+	require.Eventually(t, func() bool {
+		device, _ = c.DeviceByID(sim.DeviceID())
+		return device != nil
+	}, 2*time.Second, 10*time.Millisecond)
+	return c, device, sim
+}
+
+// startTransport starts a controller on an in-memory transport and
+// discards its startup scan.
+func startTransport(t *testing.T, opts ...govee.Option) (*govee.Controller, *goveetest.Transport) {
+	t.Helper()
+	transport := goveetest.NewTransport()
+	c := goveetest.StartController(t, append([]govee.Option{govee.WithTransport(transport)}, opts...)...)
+	<-transport.Sent()
+	return c, transport
+}
+
+// announce announces a device through the transport and waits for the
+// controller to register it.
+func announce(t *testing.T, c *govee.Controller, transport *goveetest.Transport, ip, id, sku string) *govee.Device {
+	t.Helper()
+	require.NoError(t, transport.Announce(ip, id, sku))
+	var device *govee.Device
+	require.Eventually(t, func() bool {
+		device, _ = c.DeviceByID(id)
+		return device != nil && device.SKU() == sku
+	}, time.Second, time.Millisecond)
+	return device
+}
+
+// startWithTransport starts a controller on an in-memory transport with a
+// single device at 10.0.0.5 in the given state.
+func startWithTransport(t *testing.T, state govee.DeviceState, opts ...govee.Option) (*goveetest.Transport, *govee.Device) {
+	t.Helper()
+	c, transport := startTransport(t, opts...)
+	device := announce(t, c, transport, "10.0.0.5", "AA:BB", "H6159")
+	require.NoError(t, transport.DeliverStatus("10.0.0.5", state))
+	require.Eventually(t, func() bool {
+		return device.State() == state.State && device.Brightness() == state.Brightness &&
+			device.Color() == state.Color && device.ColorKelvin() == state.ColorKelvin
+	}, time.Second, time.Millisecond)
+	return transport, device
+}
+
+// nextCommand returns the next message with the command cmd sent through
+// the transport, skipping any others. It fails the test if none is sent
+// within a second.
+func nextCommand(t *testing.T, transport *goveetest.Transport, cmd string) goveetest.Packet {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case p := <-transport.Sent():
+			if p.Msg.MSG.CMD == cmd {
+				return p
+			}
+		case <-timeout:
+			t.Fatalf("no %s message sent", cmd)
+		}
+	}
+}
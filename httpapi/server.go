@@ -0,0 +1,365 @@
+// Package httpapi exposes a govee.Controller over HTTP, so dashboards and
+// services written in other languages can list and drive the lights.
+//
+// The API is described by the OpenAPI document served at /openapi.json:
+//
+//	GET  /devices               list every known device
+//	GET  /devices/{id}          one device, by device ID or IP address
+//	PUT  /devices/{id}/state    apply a JSON state delta to a device
+//	POST /scan                  scan and list the devices that answered
+//	POST /groups/{name}/state   apply a JSON state delta to a group
+//	GET  /events                Server-Sent Events stream of state changes
+//
+// Mount a Server on any http.ServeMux or serve it directly:
+//
+//	http.ListenAndServe(":8080", httpapi.New(controller))
+package httpapi
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	govee "github.com/swrm-io/go-vee"
+)
+
+// Defaults used by New when no options are supplied.
+const (
+	DefaultScanWait       = 2 * time.Second
+	DefaultCommandTimeout = 5 * time.Second
+)
+
+// maxBodySize limits the size of request bodies.
+const maxBodySize = 1 << 16
+
+//go:embed openapi.json
+var openAPI []byte
+
+// Server is an http.Handler serving the API for a controller.
+type Server struct {
+	controller     *govee.Controller
+	logger         *slog.Logger
+	scanWait       time.Duration
+	commandTimeout time.Duration
+	mux            *http.ServeMux
+}
+
+// Option configures a Server created by New.
+type Option func(*Server)
+
+// WithLogger sets the logger used for request errors. By default nothing
+// is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithScanWait sets how long POST /scan waits for devices to answer when
+// the request does not say.
+func WithScanWait(wait time.Duration) Option {
+	return func(s *Server) {
+		if wait > 0 {
+			s.scanWait = wait
+		}
+	}
+}
+
+// WithCommandTimeout sets how long a state change may take before the
+// request fails with 504 Gateway Timeout.
+func WithCommandTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		if timeout > 0 {
+			s.commandTimeout = timeout
+		}
+	}
+}
+
+// New returns a Server for controller. The controller must be started
+// separately.
+func New(controller *govee.Controller, opts ...Option) *Server {
+	s := &Server{
+		controller:     controller,
+		logger:         slog.New(slog.DiscardHandler),
+		scanWait:       DefaultScanWait,
+		commandTimeout: DefaultCommandTimeout,
+		mux:            http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.mux.HandleFunc("GET /devices", s.listDevices)
+	s.mux.HandleFunc("GET /devices/{id}", s.getDevice)
+	s.mux.HandleFunc("PUT /devices/{id}/state", s.putDeviceState)
+	s.mux.HandleFunc("POST /scan", s.scan)
+	s.mux.HandleFunc("POST /groups/{name}/state", s.putGroupState)
+	s.mux.HandleFunc("GET /events", s.events)
+	s.mux.HandleFunc("GET /openapi.json", s.openAPI)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ApplyResponse is the body of a successful or partly failed PUT
+// /devices/{id}/state.
+type ApplyResponse struct {
+	Sent    []string          `json:"sent"`
+	Skipped []string          `json:"skipped"`
+	Failed  map[string]string `json:"failed,omitempty"`
+}
+
+// GroupResponse is the body of POST /groups/{name}/state. Failed maps the
+// device ID, or IP address, of each device that failed to its error.
+type GroupResponse struct {
+	Devices []string          `json:"devices"`
+	Failed  map[string]string `json:"failed,omitempty"`
+}
+
+// Error is the body of every error response.
+type Error struct {
+	Error string `json:"error"`
+}
+
+// listDevices serves GET /devices.
+func (s *Server) listDevices(w http.ResponseWriter, _ *http.Request) {
+	s.writeJSON(w, http.StatusOK, snapshots(s.controller.Devices()))
+}
+
+// getDevice serves GET /devices/{id}.
+func (s *Server) getDevice(w http.ResponseWriter, r *http.Request) {
+	device, err := s.device(r.PathValue("id"))
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, device.Snapshot())
+}
+
+// putDeviceState serves PUT /devices/{id}/state.
+func (s *Server) putDeviceState(w http.ResponseWriter, r *http.Request) {
+	device, err := s.device(r.PathValue("id"))
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	delta, err := decodeDelta(w, r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.commandTimeout)
+	defer cancel()
+	result, err := device.Apply(ctx, delta)
+	if errors.Is(err, govee.ErrInvalidDelta) {
+		s.writeError(w, err)
+		return
+	}
+
+	response := ApplyResponse{
+		Sent:    append([]string{}, result.Sent...),
+		Skipped: append([]string{}, result.Skipped...),
+	}
+	for part, err := range result.Failed {
+		if response.Failed == nil {
+			response.Failed = map[string]string{}
+		}
+		response.Failed[part] = err.Error()
+	}
+	s.writeJSON(w, statusFor(err, http.StatusOK), response)
+}
+
+// scan serves POST /scan. The optional wait query parameter overrides
+// how long to wait for answers, such as "?wait=500ms".
+func (s *Server) scan(w http.ResponseWriter, r *http.Request) {
+	wait := s.scanWait
+	if value := r.URL.Query().Get("wait"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			s.writeError(w, fmt.Errorf("%w: invalid wait %q", errBadRequest, value))
+			return
+		}
+		wait = parsed
+	}
+
+	devices, err := s.controller.Scan(r.Context(), wait)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, snapshots(devices))
+}
+
+// putGroupState serves POST /groups/{name}/state.
+func (s *Server) putGroupState(w http.ResponseWriter, r *http.Request) {
+	group, err := s.controller.Group(r.PathValue("name"))
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	delta, err := decodeDelta(w, r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if delta.Color != nil && delta.ColorKelvin != nil {
+		s.writeError(w, fmt.Errorf("%w: both color and color temperature set", govee.ErrInvalidDelta))
+		return
+	}
+
+	response := GroupResponse{Devices: []string{}}
+	for _, d := range group.Devices() {
+		response.Devices = append(response.Devices, cmp.Or(d.DeviceID(), d.IP()))
+	}
+	slices.Sort(response.Devices)
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.commandTimeout)
+	defer cancel()
+	var errs []error
+	for key, err := range group.Apply(ctx, delta) {
+		if response.Failed == nil {
+			response.Failed = map[string]string{}
+		}
+		response.Failed[key] = err.Error()
+		errs = append(errs, err)
+	}
+	s.writeJSON(w, statusFor(errors.Join(errs...), http.StatusOK), response)
+}
+
+// StateChangedEvent is the data of a stateChanged Server-Sent Event.
+type StateChangedEvent struct {
+	Old govee.DeviceState `json:"old"`
+	New govee.DeviceState `json:"new"`
+}
+
+// events serves GET /events, streaming a stateChanged event for every
+// state change until the client disconnects.
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	// Subscribe before sending the headers, so a client that saw the
+	// response start does not miss a change.
+	events := s.controller.Subscribe(r.Context(), func(e govee.Event) bool {
+		_, ok := e.(govee.StateChanged)
+		return ok
+	})
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		s.logger.Error("Event stream not supported", "error", err)
+		return
+	}
+	for e := range events {
+		changed := e.(govee.StateChanged)
+		data, err := json.Marshal(StateChangedEvent{Old: changed.Old, New: changed.New})
+		if err != nil {
+			s.logger.Error("Failed to encode event", "error", err)
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "event: stateChanged\ndata: %s\n\n", data); err != nil {
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// openAPI serves the OpenAPI document.
+func (s *Server) openAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPI)
+}
+
+// device finds a device by device ID or IP address.
+func (s *Server) device(id string) (*govee.Device, error) {
+	device, err := s.controller.DeviceByID(id)
+	if err != nil {
+		device, err = s.controller.DeviceByIP(id)
+	}
+	return device, err
+}
+
+// errBadRequest marks errors caused by an invalid request.
+var errBadRequest = errors.New("bad request")
+
+// decodeDelta reads a JSON state delta from the request body.
+func decodeDelta(w http.ResponseWriter, r *http.Request) (govee.StateDelta, error) {
+	var delta govee.StateDelta
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&delta); err != nil {
+		return delta, fmt.Errorf("%w: invalid state delta: %w", errBadRequest, err)
+	}
+	switch {
+	case delta.State != nil && *delta.State > 1:
+		return delta, fmt.Errorf("%w: state must be 0 or 1", errBadRequest)
+	case delta.Brightness != nil && *delta.Brightness > 100:
+		return delta, fmt.Errorf("%w: brightness must be at most 100", errBadRequest)
+	case delta.Color != nil && *delta.Color != govee.NewColor(delta.Color.R, delta.Color.G, delta.Color.B):
+		return delta, fmt.Errorf("%w: color components must be at most 255", errBadRequest)
+	case delta.ColorKelvin != nil && *delta.ColorKelvin != govee.NewColorKelvin(uint(*delta.ColorKelvin)):
+		return delta, fmt.Errorf("%w: color temperature must be between 2000 and 9000", errBadRequest)
+	}
+	return delta, nil
+}
+
+// snapshots returns the state of devices, sorted by device ID then IP.
+func snapshots(devices []*govee.Device) []govee.DeviceState {
+	states := make([]govee.DeviceState, 0, len(devices))
+	for _, d := range devices {
+		states = append(states, d.Snapshot())
+	}
+	slices.SortFunc(states, func(a, b govee.DeviceState) int {
+		return cmp.Or(cmp.Compare(a.DeviceID, b.DeviceID), cmp.Compare(a.IP, b.IP))
+	})
+	return states
+}
+
+// statusFor returns the HTTP status for err, or ok if err is nil.
+func statusFor(err error, ok int) int {
+	switch {
+	case err == nil:
+		return ok
+	case errors.Is(err, errBadRequest), errors.Is(err, govee.ErrInvalidDelta):
+		return http.StatusBadRequest
+	case errors.Is(err, govee.ErrNoDeviceFound), errors.Is(err, govee.ErrNoGroupFound):
+		return http.StatusNotFound
+	case errors.Is(err, govee.ErrNotStarted):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// writeError writes err as a JSON error response.
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	status := statusFor(err, http.StatusInternalServerError)
+	if status >= http.StatusInternalServerError {
+		s.logger.Error("Request failed", "status", status, "error", err)
+	}
+	s.writeJSON(w, status, Error{Error: err.Error()})
+}
+
+// writeJSON writes v as the JSON response body.
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logger.Error("Failed to write response", "error", err)
+	}
+}
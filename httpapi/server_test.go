@@ -0,0 +1,250 @@
+package httpapi_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+	"github.com/swrm-io/go-vee/goveetest"
+	"github.com/swrm-io/go-vee/httpapi"
+)
+
+const (
+	kitchenID = "AA:AA:AA:AA:AA:AA:AA:01"
+	hallID    = "AA:AA:AA:AA:AA:AA:AA:02"
+)
+
+// startServer starts two simulated devices, a controller that knows both
+// and an API server in front of it.
+func startServer(t *testing.T) (*httptest.Server, *govee.Controller, map[string]*goveetest.Device) {
+	t.Helper()
+	ports, err := goveetest.FreePorts()
+	require.NoError(t, err)
+
+	sims := map[string]*goveetest.Device{}
+	for ip, id := range map[string]string{"127.0.0.2": kitchenID, "127.0.0.3": hallID} {
+		sim, err := goveetest.NewDevice(ip,
+			goveetest.WithPorts(ports),
+			goveetest.WithDeviceID(id),
+			goveetest.WithState(govee.DeviceState{State: 0, Brightness: 50, ColorKelvin: 4000}),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = sim.Close() })
+		sims[id] = sim
+	}
+
+	// Scans go to an address nothing answers on; the devices are added
+	// by IP so each test sees exactly these two.
+	c := goveetest.StartController(t,
+		govee.WithMulticastAddress("127.0.0.9"),
+		govee.WithPorts(ports.Scan, ports.Listen, ports.Command),
+	)
+
+	for _, sim := range sims {
+		_, err := c.AddDevice(sim.IP(), govee.DeviceOptions{})
+		require.NoError(t, err)
+	}
+	require.Eventually(t, func() bool {
+		for id := range sims {
+			device, err := c.DeviceByID(id)
+			if err != nil || device.Brightness() != 50 {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, 10*time.Millisecond)
+
+	server := httptest.NewServer(httpapi.New(c, httpapi.WithScanWait(100*time.Millisecond)))
+	t.Cleanup(server.Close)
+	return server, c, sims
+}
+
+// call sends a request with an optional JSON body and decodes the JSON
+// response into out, returning the status code.
+func call(t *testing.T, method, url, body string, out any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	if out != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp.StatusCode
+}
+
+func TestListDevices(t *testing.T) {
+	server, _, _ := startServer(t)
+
+	var devices []govee.DeviceState
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, server.URL+"/devices", "", &devices))
+	require.Len(t, devices, 2)
+	assert.Equal(t, kitchenID, devices[0].DeviceID)
+	assert.Equal(t, "127.0.0.2", devices[0].IP)
+	assert.Equal(t, "H6159", devices[0].SKU)
+	assert.Equal(t, govee.Brightness(50), devices[0].Brightness)
+	assert.Equal(t, hallID, devices[1].DeviceID)
+}
+
+func TestGetDevice(t *testing.T) {
+	server, _, _ := startServer(t)
+
+	var device govee.DeviceState
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, server.URL+"/devices/"+hallID, "", &device))
+	assert.Equal(t, "127.0.0.3", device.IP)
+	assert.Equal(t, govee.ColorKelvin(4000), device.ColorKelvin)
+
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, server.URL+"/devices/127.0.0.2", "", &device))
+	assert.Equal(t, kitchenID, device.DeviceID)
+
+	var apiErr httpapi.Error
+	assert.Equal(t, http.StatusNotFound, call(t, http.MethodGet, server.URL+"/devices/nope", "", &apiErr))
+	assert.Contains(t, apiErr.Error, "no device found")
+}
+
+func TestPutDeviceState(t *testing.T) {
+	server, _, sims := startServer(t)
+
+	var result httpapi.ApplyResponse
+	status := call(t, http.MethodPut, server.URL+"/devices/"+kitchenID+"/state",
+		`{"state": 1, "brightness": 50, "color": {"r": 255, "g": 127, "b": 80}}`, &result)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{govee.PartColor, govee.PartState}, result.Sent)
+	assert.Equal(t, []string{govee.PartBrightness}, result.Skipped)
+	assert.Empty(t, result.Failed)
+
+	require.Eventually(t, func() bool {
+		state := sims[kitchenID].State()
+		return state.State == 1 && state.Color == govee.Color{R: 255, G: 127, B: 80}
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestPutDeviceStateInvalid(t *testing.T) {
+	server, _, _ := startServer(t)
+	url := server.URL + "/devices/" + kitchenID + "/state"
+
+	for _, body := range []string{
+		`not json`,
+		`{"brightness": 150}`,
+		`{"color": {"r": 300, "g": 0, "b": 0}}`,
+		`{"colorKelvin": 100}`,
+		`{"state": 2}`,
+		`{"unknown": 1}`,
+		`{"color": {"r": 1, "g": 2, "b": 3}, "colorKelvin": 3000}`,
+	} {
+		var apiErr httpapi.Error
+		assert.Equal(t, http.StatusBadRequest, call(t, http.MethodPut, url, body, &apiErr), body)
+		assert.NotEmpty(t, apiErr.Error, body)
+	}
+
+	assert.Equal(t, http.StatusNotFound, call(t, http.MethodPut, server.URL+"/devices/nope/state", `{"state": 1}`, nil))
+}
+
+func TestScan(t *testing.T) {
+	server, _, _ := startServer(t)
+
+	var devices []govee.DeviceState
+	require.Equal(t, http.StatusOK, call(t, http.MethodPost, server.URL+"/scan?wait=200ms", "", &devices))
+	require.Len(t, devices, 2)
+	assert.Equal(t, kitchenID, devices[0].DeviceID)
+	assert.Equal(t, hallID, devices[1].DeviceID)
+
+	assert.Equal(t, http.StatusBadRequest, call(t, http.MethodPost, server.URL+"/scan?wait=soon", "", nil))
+}
+
+func TestPutGroupState(t *testing.T) {
+	server, c, sims := startServer(t)
+	c.NewGroup("downstairs", govee.ByDeviceID(kitchenID, hallID))
+
+	var result httpapi.GroupResponse
+	status := call(t, http.MethodPost, server.URL+"/groups/downstairs/state", `{"state": 1, "brightness": 20}`, &result)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{kitchenID, hallID}, result.Devices)
+	assert.Empty(t, result.Failed)
+
+	require.Eventually(t, func() bool {
+		for _, sim := range sims {
+			if state := sim.State(); state.State != 1 || state.Brightness != 20 {
+				return false
+			}
+		}
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, http.StatusNotFound, call(t, http.MethodPost, server.URL+"/groups/upstairs/state", `{"state": 1}`, nil))
+	assert.Equal(t, http.StatusBadRequest, call(t, http.MethodPost, server.URL+"/groups/downstairs/state", `{"brightness": 101}`, nil))
+}
+
+func TestEvents(t *testing.T) {
+	server, c, sims := startServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	sims[hallID].SetState(govee.DeviceState{State: 1, Brightness: 80, ColorKelvin: 4000})
+	device, err := c.DeviceByID(hallID)
+	require.NoError(t, err)
+	// The subscription starts with the response headers, so this status
+	// change cannot be missed.
+	_, err = device.RequestStatus(ctx)
+	require.NoError(t, err)
+
+	lines := bufio.NewScanner(resp.Body)
+	require.True(t, lines.Scan())
+	assert.Equal(t, "event: stateChanged", lines.Text())
+	require.True(t, lines.Scan())
+	data, ok := strings.CutPrefix(lines.Text(), "data: ")
+	require.True(t, ok, lines.Text())
+
+	var event httpapi.StateChangedEvent
+	require.NoError(t, json.Unmarshal([]byte(data), &event))
+	assert.Equal(t, hallID, event.New.DeviceID)
+	assert.Equal(t, govee.Brightness(50), event.Old.Brightness)
+	assert.Equal(t, govee.Brightness(80), event.New.Brightness)
+	assert.Equal(t, govee.State(1), event.New.State)
+}
+
+func TestOpenAPI(t *testing.T) {
+	server, _, _ := startServer(t)
+
+	resp, err := http.Get(server.URL + "/openapi.json")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var doc struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(body, &doc))
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	for path, method := range map[string]string{
+		"/devices":             "get",
+		"/devices/{id}":        "get",
+		"/devices/{id}/state":  "put",
+		"/scan":                "post",
+		"/groups/{name}/state": "post",
+		"/events":              "get",
+	} {
+		assert.Contains(t, doc.Paths[path], method, path)
+	}
+}
@@ -0,0 +1,151 @@
+// Package metrics exports the devices and internals of a govee.Controller
+// as Prometheus metrics.
+//
+// Serve them on /metrics alongside the rest of an application:
+//
+//	http.Handle("/metrics", metrics.Handler(controller))
+//
+// or register the collector with an existing registry:
+//
+//	prometheus.MustRegister(metrics.NewCollector(controller))
+//
+// Per-device metrics are labeled with device_id, which falls back to the
+// IP address for devices no scan has identified yet. govee_device_info
+// carries the IP address, SKU and firmware versions as labels, to join
+// with the other device metrics.
+package metrics
+
+import (
+	"cmp"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	govee "github.com/swrm-io/go-vee"
+)
+
+// namespace prefixes every metric name.
+const namespace = "govee"
+
+var (
+	deviceLabels = []string{"device_id"}
+
+	deviceInfo = prometheus.NewDesc(namespace+"_device_info",
+		"Device metadata, with firmware versions as labels. Always 1.",
+		[]string{"device_id", "ip", "sku", "ble_version_hard", "ble_version_soft", "wifi_version_hard", "wifi_version_soft"}, nil)
+	deviceActive = prometheus.NewDesc(namespace+"_device_active",
+		"Whether the device has been seen within the controller's active window.",
+		deviceLabels, nil)
+	deviceLastSeen = prometheus.NewDesc(namespace+"_device_last_seen_age_seconds",
+		"Seconds since the device was last heard from.",
+		deviceLabels, nil)
+	deviceOn = prometheus.NewDesc(namespace+"_device_on",
+		"Whether the device is on.",
+		deviceLabels, nil)
+	deviceBrightness = prometheus.NewDesc(namespace+"_device_brightness_percent",
+		"Brightness of the device.",
+		deviceLabels, nil)
+	deviceColor = prometheus.NewDesc(namespace+"_device_color",
+		"Color of the device, by RGB channel.",
+		[]string{"device_id", "channel"}, nil)
+	deviceColorKelvin = prometheus.NewDesc(namespace+"_device_color_temperature_kelvin",
+		"Color temperature of the device, or 0 while it shows an RGB color.",
+		deviceLabels, nil)
+
+	packetsReceived = prometheus.NewDesc(namespace+"_packets_received_total",
+		"Packets received from devices, by command.",
+		[]string{"cmd"}, nil)
+	unknownCommands = prometheus.NewDesc(namespace+"_unknown_commands_total",
+		"Packets received with a command the controller does not handle.",
+		nil, nil)
+	parseErrors = prometheus.NewDesc(namespace+"_parse_errors_total",
+		"Packets received that could not be parsed.",
+		nil, nil)
+	droppedCommands = prometheus.NewDesc(namespace+"_dropped_commands_total",
+		"Commands dropped because the send queue was full.",
+		nil, nil)
+	droppedEvents = prometheus.NewDesc(namespace+"_dropped_events_total",
+		"Events dropped for subscribers that fell behind.",
+		nil, nil)
+	sendErrors = prometheus.NewDesc(namespace+"_send_errors_total",
+		"Network writes that failed.",
+		nil, nil)
+)
+
+// Collector is a prometheus.Collector reading a controller's devices and
+// counters on every scrape.
+type Collector struct {
+	controller *govee.Controller
+}
+
+// NewCollector returns a Collector for controller.
+func NewCollector(controller *govee.Controller) *Collector {
+	return &Collector{controller: controller}
+}
+
+// Handler returns an http.Handler serving the metrics of controller, and
+// nothing else, in the Prometheus exposition format.
+func Handler(controller *govee.Controller) http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewCollector(controller))
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		deviceInfo, deviceActive, deviceLastSeen, deviceOn, deviceBrightness, deviceColor, deviceColorKelvin,
+		packetsReceived, unknownCommands, parseErrors, droppedCommands, droppedEvents, sendErrors,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	now := time.Now()
+	for _, d := range c.controller.Devices() {
+		collectDevice(ch, d.Snapshot(), d.Active(), now)
+	}
+
+	stats := c.controller.Stats()
+	for cmd, count := range stats.PacketsReceived {
+		ch <- prometheus.MustNewConstMetric(packetsReceived, prometheus.CounterValue, float64(count), cmd)
+	}
+	ch <- prometheus.MustNewConstMetric(unknownCommands, prometheus.CounterValue, float64(stats.UnknownCommands))
+	ch <- prometheus.MustNewConstMetric(parseErrors, prometheus.CounterValue, float64(stats.ParseErrors))
+	ch <- prometheus.MustNewConstMetric(droppedCommands, prometheus.CounterValue, float64(stats.DroppedCommands))
+	ch <- prometheus.MustNewConstMetric(droppedEvents, prometheus.CounterValue, float64(stats.DroppedEvents))
+	ch <- prometheus.MustNewConstMetric(sendErrors, prometheus.CounterValue, float64(stats.SendErrors))
+}
+
+// collectDevice sends the metrics of one device.
+func collectDevice(ch chan<- prometheus.Metric, state govee.DeviceState, active bool, now time.Time) {
+	id := cmp.Or(state.DeviceID, state.IP)
+	gauge := func(desc *prometheus.Desc, value float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, append([]string{id}, labels...)...)
+	}
+
+	gauge(deviceInfo, 1, state.IP, state.SKU,
+		state.BleVersionHard.Label(), state.BleVersionSoft.Label(),
+		state.WifiVersionHard.Label(), state.WifiVersionSoft.Label())
+	gauge(deviceActive, boolValue(active))
+	if !state.LastSeen.IsZero() {
+		gauge(deviceLastSeen, now.Sub(state.LastSeen).Seconds())
+	}
+	gauge(deviceOn, boolValue(state.State == 1))
+	gauge(deviceBrightness, float64(state.Brightness))
+	gauge(deviceColor, float64(state.Color.R), "red")
+	gauge(deviceColor, float64(state.Color.G), "green")
+	gauge(deviceColor, float64(state.Color.B), "blue")
+	gauge(deviceColorKelvin, float64(state.ColorKelvin))
+}
+
+// boolValue returns 1 for true and 0 for false.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,114 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+	"github.com/swrm-io/go-vee/goveetest"
+	"github.com/swrm-io/go-vee/metrics"
+)
+
+// startController starts a controller over an in-memory transport that
+// has heard from one device, plus a malformed and an unknown packet.
+func startController(t *testing.T) *govee.Controller {
+	t.Helper()
+	transport := goveetest.NewTransport()
+	c := goveetest.StartController(t, govee.WithTransport(transport))
+
+	require.NoError(t, transport.Announce("10.0.0.5", "AA:BB", "H6159"))
+	require.NoError(t, transport.DeliverStatus("10.0.0.5", govee.DeviceState{
+		State:      1,
+		Brightness: 42,
+		Color:      govee.NewColor(255, 127, 80),
+	}))
+	require.NoError(t, transport.Deliver("10.0.0.5", "devStatus", "not a status"))
+	require.NoError(t, transport.Deliver("10.0.0.5", "reboot", map[string]any{}))
+
+	require.Eventually(t, func() bool {
+		return c.Stats().UnknownCommands == 1
+	}, time.Second, time.Millisecond)
+	require.Eventually(t, func() bool {
+		device, err := c.DeviceByID("AA:BB")
+		return err == nil && device.Brightness() == 42
+	}, time.Second, time.Millisecond)
+	return c
+}
+
+func TestCollector(t *testing.T) {
+	c := startController(t)
+
+	expected := `
+# HELP govee_device_brightness_percent Brightness of the device.
+# TYPE govee_device_brightness_percent gauge
+govee_device_brightness_percent{device_id="AA:BB"} 42
+# HELP govee_device_color Color of the device, by RGB channel.
+# TYPE govee_device_color gauge
+govee_device_color{channel="blue",device_id="AA:BB"} 80
+govee_device_color{channel="green",device_id="AA:BB"} 127
+govee_device_color{channel="red",device_id="AA:BB"} 255
+# HELP govee_device_color_temperature_kelvin Color temperature of the device, or 0 while it shows an RGB color.
+# TYPE govee_device_color_temperature_kelvin gauge
+govee_device_color_temperature_kelvin{device_id="AA:BB"} 0
+# HELP govee_device_info Device metadata, with firmware versions as labels. Always 1.
+# TYPE govee_device_info gauge
+govee_device_info{ble_version_hard="3.1.1",ble_version_soft="1.3.1",device_id="AA:BB",ip="10.0.0.5",sku="H6159",wifi_version_hard="1.0.10",wifi_version_soft="1.2.3"} 1
+# HELP govee_device_on Whether the device is on.
+# TYPE govee_device_on gauge
+govee_device_on{device_id="AA:BB"} 1
+# HELP govee_dropped_commands_total Commands dropped because the send queue was full.
+# TYPE govee_dropped_commands_total counter
+govee_dropped_commands_total 0
+# HELP govee_packets_received_total Packets received from devices, by command.
+# TYPE govee_packets_received_total counter
+govee_packets_received_total{cmd="devStatus"} 2
+govee_packets_received_total{cmd="scan"} 1
+# HELP govee_parse_errors_total Packets received that could not be parsed.
+# TYPE govee_parse_errors_total counter
+govee_parse_errors_total 1
+# HELP govee_unknown_commands_total Packets received with a command the controller does not handle.
+# TYPE govee_unknown_commands_total counter
+govee_unknown_commands_total 1
+`
+	require.NoError(t, testutil.CollectAndCompare(metrics.NewCollector(c), strings.NewReader(expected),
+		"govee_device_brightness_percent",
+		"govee_device_color",
+		"govee_device_color_temperature_kelvin",
+		"govee_device_info",
+		"govee_device_on",
+		"govee_dropped_commands_total",
+		"govee_packets_received_total",
+		"govee_parse_errors_total",
+		"govee_unknown_commands_total",
+	))
+	assert.NoError(t, testutil.CollectAndCompare(metrics.NewCollector(c), strings.NewReader(`
+# HELP govee_device_active Whether the device has been seen within the controller's active window.
+# TYPE govee_device_active gauge
+govee_device_active{device_id="AA:BB"} 1
+`), "govee_device_active"))
+}
+
+func TestHandler(t *testing.T) {
+	c := startController(t)
+	server := httptest.NewServer(metrics.Handler(c))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(body), `govee_device_last_seen_age_seconds{device_id="AA:BB"}`)
+	assert.Contains(t, string(body), `govee_send_errors_total 0`)
+	assert.Contains(t, string(body), `govee_dropped_events_total 0`)
+	assert.NotContains(t, string(body), "go_goroutines")
+}
@@ -0,0 +1,391 @@
+// Package mqttbridge publishes the devices of a govee.Controller to an MQTT
+// broker and controls them from MQTT commands, with Home Assistant MQTT
+// discovery so the lights appear in Home Assistant without configuration.
+//
+// For a device with ID 1F:80:C5:32:32:36:72:4E the bridge uses, with the
+// default prefixes:
+//
+//	govee/1F:80:C5:32:32:36:72:4E/state           retained JSON state
+//	govee/1F:80:C5:32:32:36:72:4E/set             JSON commands
+//	govee/1F:80:C5:32:32:36:72:4E/availability    "online" or "offline"
+//	homeassistant/light/govee_1F80C5323236724E/config
+//
+// State and commands use the Home Assistant JSON light schema, with
+// brightness as a percentage and color temperatures in Kelvin:
+//
+//	{"state": "ON", "brightness": 40, "color_mode": "color_temp", "color_temp": 2700}
+//	{"state": "ON", "color": {"r": 255, "g": 127, "b": 80}}
+//
+// The bridge also keeps govee/bridge/availability up to date. Set it as the
+// client's will with SetWill so Home Assistant marks every light unavailable
+// if the bridge disconnects.
+package mqttbridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	govee "github.com/swrm-io/go-vee"
+)
+
+// Defaults used by New when no options are supplied.
+const (
+	DefaultTopicPrefix          = "govee"
+	DefaultDiscoveryPrefix      = "homeassistant"
+	DefaultAvailabilityInterval = 10 * time.Second
+	DefaultCommandTimeout       = 5 * time.Second
+)
+
+// Availability payloads.
+const (
+	Online  = "online"
+	Offline = "offline"
+)
+
+// Bridge connects a controller to an MQTT broker.
+type Bridge struct {
+	controller           *govee.Controller
+	client               mqtt.Client
+	logger               *slog.Logger
+	prefix               string
+	discoveryPrefix      string
+	availabilityInterval time.Duration
+	commandTimeout       time.Duration
+
+	// announced and available record what has been published for each
+	// device. They are only used by the Run goroutine.
+	announced map[*govee.Device]string
+	available map[*govee.Device]bool
+
+	// refreshed carries the states fetched for newly announced devices
+	// to the Run goroutine.
+	refreshed chan govee.DeviceState
+
+	// tasks tracks the command handlers and refreshes still running.
+	tasks sync.WaitGroup
+}
+
+// Option configures a Bridge created by New.
+type Option func(*Bridge)
+
+// WithLogger sets the logger used for publish and command errors. By
+// default nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(b *Bridge) {
+		b.logger = logger
+	}
+}
+
+// WithTopicPrefix sets the prefix of the state, command and availability
+// topics.
+func WithTopicPrefix(prefix string) Option {
+	return func(b *Bridge) {
+		if prefix != "" {
+			b.prefix = prefix
+		}
+	}
+}
+
+// WithDiscoveryPrefix sets the Home Assistant discovery prefix.
+func WithDiscoveryPrefix(prefix string) Option {
+	return func(b *Bridge) {
+		if prefix != "" {
+			b.discoveryPrefix = prefix
+		}
+	}
+}
+
+// WithAvailabilityInterval sets how often Device.Active is checked to
+// update the availability topics.
+func WithAvailabilityInterval(interval time.Duration) Option {
+	return func(b *Bridge) {
+		if interval > 0 {
+			b.availabilityInterval = interval
+		}
+	}
+}
+
+// WithCommandTimeout sets how long a command from MQTT may take to send.
+func WithCommandTimeout(timeout time.Duration) Option {
+	return func(b *Bridge) {
+		if timeout > 0 {
+			b.commandTimeout = timeout
+		}
+	}
+}
+
+// New returns a Bridge publishing the devices of controller through
+// client. The client must be connected before Run is called, and the
+// controller started separately.
+func New(controller *govee.Controller, client mqtt.Client, opts ...Option) *Bridge {
+	b := &Bridge{
+		controller:           controller,
+		client:               client,
+		logger:               slog.New(slog.DiscardHandler),
+		prefix:               DefaultTopicPrefix,
+		discoveryPrefix:      DefaultDiscoveryPrefix,
+		availabilityInterval: DefaultAvailabilityInterval,
+		commandTimeout:       DefaultCommandTimeout,
+		announced:            map[*govee.Device]string{},
+		available:            map[*govee.Device]bool{},
+		refreshed:            make(chan govee.DeviceState),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// SetWill configures opts to publish "offline" to the bridge availability
+// topic under prefix if the client disconnects unexpectedly.
+func SetWill(opts *mqtt.ClientOptions, prefix string) *mqtt.ClientOptions {
+	return opts.SetWill(bridgeAvailabilityTopic(prefix), Offline, 1, true)
+}
+
+// Run publishes every device and serves commands until ctx is canceled or
+// the controller shuts down. It marks the bridge offline before
+// returning.
+func (b *Bridge) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Subscribe before publishing the current devices, so a device found
+	// in between is not missed.
+	events := b.controller.Subscribe(ctx, nil)
+
+	if err := b.wait(ctx, b.client.Subscribe(b.prefix+"/+/set", 1, b.handleSet)); err != nil {
+		return fmt.Errorf("failed to subscribe to commands: %w", err)
+	}
+	if err := b.publish(ctx, bridgeAvailabilityTopic(b.prefix), Online); err != nil {
+		return err
+	}
+	for _, d := range b.controller.Devices() {
+		b.update(ctx, d)
+	}
+
+	ticker := time.NewTicker(b.availabilityInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				cancel()
+				return b.stop()
+			}
+			b.handleEvent(ctx, e)
+		case state := <-b.refreshed:
+			b.publishState(ctx, state)
+		case <-ticker.C:
+			for _, d := range b.controller.Devices() {
+				b.updateAvailability(ctx, d)
+			}
+		}
+	}
+}
+
+// stop unsubscribes from commands, waits for the running tasks and marks
+// the bridge offline.
+func (b *Bridge) stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), b.commandTimeout)
+	defer cancel()
+	err := b.wait(ctx, b.client.Unsubscribe(b.prefix+"/+/set"))
+	b.tasks.Wait()
+	return errors.Join(err, b.publish(ctx, bridgeAvailabilityTopic(b.prefix), Offline))
+}
+
+// handleEvent publishes the changes an event reports.
+func (b *Bridge) handleEvent(ctx context.Context, e govee.Event) {
+	d := e.EventDevice()
+	switch e := e.(type) {
+	case govee.DeviceRemoved:
+		if _, ok := b.announced[d]; ok {
+			b.setAvailability(ctx, d, false)
+		}
+		delete(b.announced, d)
+		delete(b.available, d)
+	case govee.StateChanged:
+		b.update(ctx, d)
+		b.publishState(ctx, e.New)
+	default:
+		b.update(ctx, d)
+	}
+}
+
+// update announces d if it has not been announced yet and publishes its
+// availability. Newly announced devices are asked for their status, which
+// is published once it arrives.
+func (b *Bridge) update(ctx context.Context, d *govee.Device) {
+	state := d.Snapshot()
+	if state.DeviceID == "" {
+		// Devices added by IP are published once a scan names them.
+		return
+	}
+	if b.announced[d] != state.DeviceID {
+		if err := b.announce(ctx, state); err != nil {
+			b.logger.Error("Failed to publish discovery config", "device", d, "error", err)
+			return
+		}
+		b.announced[d] = state.DeviceID
+		delete(b.available, d)
+		b.refresh(ctx, d)
+	}
+	b.updateAvailability(ctx, d)
+}
+
+// refresh requests the status of d in the background and hands it to the
+// Run goroutine to publish.
+func (b *Bridge) refresh(ctx context.Context, d *govee.Device) {
+	b.tasks.Add(1)
+	go func() {
+		defer b.tasks.Done()
+		requestCtx, cancel := context.WithTimeout(ctx, b.commandTimeout)
+		defer cancel()
+		state, err := d.RequestStatus(requestCtx)
+		if err != nil {
+			b.logger.Warn("Failed to request state", "device", d, "error", err)
+			return
+		}
+		select {
+		case b.refreshed <- state:
+		case <-ctx.Done():
+		}
+	}()
+}
+
+// publishState publishes the state of an announced device.
+func (b *Bridge) publishState(ctx context.Context, state govee.DeviceState) {
+	if state.DeviceID == "" {
+		return
+	}
+	payload, err := json.Marshal(newLightState(state))
+	if err != nil {
+		b.logger.Error("Failed to encode state", "device", state.DeviceID, "error", err)
+		return
+	}
+	if err := b.publish(ctx, b.deviceTopic(state.DeviceID, "state"), string(payload)); err != nil {
+		b.logger.Error("Failed to publish state", "device", state.DeviceID, "error", err)
+	}
+}
+
+// updateAvailability publishes the availability of an announced device
+// if Device.Active changed since it was last published.
+func (b *Bridge) updateAvailability(ctx context.Context, d *govee.Device) {
+	if _, ok := b.announced[d]; !ok {
+		return
+	}
+	active := d.Active()
+	if published, ok := b.available[d]; ok && published == active {
+		return
+	}
+	b.setAvailability(ctx, d, active)
+}
+
+// setAvailability publishes the availability of an announced device.
+func (b *Bridge) setAvailability(ctx context.Context, d *govee.Device, available bool) {
+	payload := Offline
+	if available {
+		payload = Online
+	}
+	if err := b.publish(ctx, b.deviceTopic(b.announced[d], "availability"), payload); err != nil {
+		b.logger.Error("Failed to publish availability", "device", d, "error", err)
+		return
+	}
+	b.available[d] = available
+}
+
+// handleSet applies a JSON command received on a set topic.
+func (b *Bridge) handleSet(_ mqtt.Client, msg mqtt.Message) {
+	id, ok := strings.CutPrefix(msg.Topic(), b.prefix+"/")
+	if ok {
+		id, ok = strings.CutSuffix(id, "/set")
+	}
+	if !ok {
+		return
+	}
+
+	var cmd lightCommand
+	if err := json.Unmarshal(msg.Payload(), &cmd); err != nil {
+		b.logger.Warn("Invalid command", "topic", msg.Topic(), "error", err)
+		return
+	}
+	device, err := b.controller.DeviceByID(id)
+	if err != nil {
+		b.logger.Warn("Command for unknown device", "topic", msg.Topic(), "error", err)
+		return
+	}
+
+	// Paho delivers messages one at a time; sending can take a while, so
+	// it must not hold up the next message.
+	b.tasks.Add(1)
+	go func() {
+		defer b.tasks.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), b.commandTimeout)
+		defer cancel()
+		if _, err := device.Apply(ctx, cmd.delta()); err != nil {
+			b.logger.Error("Failed to apply command", "device", device, "error", err)
+			return
+		}
+		// Ask for the new state so the state topic follows promptly.
+		if _, err := device.RequestStatus(ctx); err != nil {
+			b.logger.Warn("Failed to refresh state", "device", device, "error", err)
+		}
+	}()
+}
+
+// announce publishes the Home Assistant discovery config for a device.
+func (b *Bridge) announce(ctx context.Context, state govee.DeviceState) error {
+	payload, err := json.Marshal(b.discoveryConfig(state))
+	if err != nil {
+		return err
+	}
+	topic := fmt.Sprintf("%s/light/%s/config", b.discoveryPrefix, uniqueID(state.DeviceID))
+	return b.publish(ctx, topic, string(payload))
+}
+
+// publish sends a retained message and waits for the broker to accept it.
+func (b *Bridge) publish(ctx context.Context, topic, payload string) error {
+	if err := b.wait(ctx, b.client.Publish(topic, 1, true, payload)); err != nil {
+		return fmt.Errorf("failed to publish %s: %w", topic, err)
+	}
+	return nil
+}
+
+// wait blocks until token completes or ctx expires.
+func (b *Bridge) wait(ctx context.Context, token mqtt.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deviceTopic returns the topic for a device ID and suffix.
+func (b *Bridge) deviceTopic(id, suffix string) string {
+	return b.prefix + "/" + id + "/" + suffix
+}
+
+// bridgeAvailabilityTopic returns the bridge availability topic under
+// prefix.
+func bridgeAvailabilityTopic(prefix string) string {
+	return prefix + "/bridge/availability"
+}
+
+// uniqueID returns the Home Assistant unique ID of a device. Discovery
+// topics only allow letters, digits, underscores and hyphens.
+func uniqueID(deviceID string) string {
+	return "govee_" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return -1
+	}, deviceID)
+}
@@ -0,0 +1,195 @@
+package mqttbridge_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	server "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+	"github.com/swrm-io/go-vee/goveetest"
+	"github.com/swrm-io/go-vee/mqttbridge"
+)
+
+const deviceID = "1F:80:C5:32:32:36:72:4E"
+
+// broker is an in-process MQTT broker that records every message
+// published to it.
+type broker struct {
+	*server.Server
+	addr string
+
+	mu       sync.Mutex
+	messages map[string][]string
+}
+
+// startBroker starts a broker on a free loopback port until the test ends.
+func startBroker(t *testing.T) *broker {
+	t.Helper()
+	b := &broker{
+		Server:   server.New(&server.Options{InlineClient: true, Logger: slog.New(slog.DiscardHandler)}),
+		messages: map[string][]string{},
+	}
+	require.NoError(t, b.AddHook(new(auth.AllowHook), nil))
+	tcp := listeners.NewTCP(listeners.Config{ID: "tcp", Address: "127.0.0.1:0"})
+	require.NoError(t, b.AddListener(tcp))
+	b.addr = tcp.Address()
+	require.NoError(t, b.Serve())
+	t.Cleanup(func() { _ = b.Close() })
+
+	require.NoError(t, b.Subscribe("#", 1, func(_ *server.Client, _ packets.Subscription, pk packets.Packet) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.messages[pk.TopicName] = append(b.messages[pk.TopicName], string(pk.Payload))
+	}))
+	return b
+}
+
+// last returns the last payload published to topic.
+func (b *broker) last(topic string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if msgs := b.messages[topic]; len(msgs) > 0 {
+		return msgs[len(msgs)-1]
+	}
+	return ""
+}
+
+// history returns every payload published to topic.
+func (b *broker) history(topic string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string{}, b.messages[topic]...)
+}
+
+// startBridge starts a simulated device, a controller that knows it and a
+// bridge connected to a new broker. The bridge runs until stop is called
+// or the test ends.
+func startBridge(t *testing.T, opts ...govee.Option) (*broker, *goveetest.Device, func()) {
+	t.Helper()
+	b := startBroker(t)
+
+	ports, err := goveetest.FreePorts()
+	require.NoError(t, err)
+	sim, err := goveetest.NewDevice("127.0.0.2",
+		goveetest.WithPorts(ports),
+		goveetest.WithDeviceID(deviceID),
+		goveetest.WithSKU("H6008"),
+		goveetest.WithVersions(govee.NewVersion(3, 1, 1), govee.NewVersion(1, 3, 1), govee.NewVersion(1, 0, 10), govee.NewVersion(1, 2, 3)),
+		goveetest.WithState(govee.DeviceState{State: 1, Brightness: 60, ColorKelvin: 3000}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sim.Close() })
+
+	c := goveetest.StartController(t, append([]govee.Option{
+		govee.WithMulticastAddress(sim.IP()),
+		govee.WithPorts(ports.Scan, ports.Listen, ports.Command),
+	}, opts...)...)
+
+	clientOpts := mqtt.NewClientOptions().AddBroker("tcp://" + b.addr).SetClientID("govee-bridge")
+	mqttbridge.SetWill(clientOpts, mqttbridge.DefaultTopicPrefix)
+	client := mqtt.NewClient(clientOpts)
+	token := client.Connect()
+	require.True(t, token.WaitTimeout(2*time.Second))
+	require.NoError(t, token.Error())
+	t.Cleanup(func() { client.Disconnect(0) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	bridge := mqttbridge.New(c, client, mqttbridge.WithAvailabilityInterval(20*time.Millisecond))
+	go func() { done <- bridge.Run(ctx) }()
+	stop := func() {
+		cancel()
+		require.NoError(t, <-done)
+	}
+	t.Cleanup(func() {
+		if ctx.Err() == nil {
+			stop()
+		}
+	})
+	return b, sim, stop
+}
+
+func TestBridgePublishesDevice(t *testing.T) {
+	b, _, stop := startBridge(t)
+
+	require.Eventually(t, func() bool {
+		return b.last("govee/"+deviceID+"/state") != ""
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.JSONEq(t, `{"state": "ON", "brightness": 60, "color_mode": "color_temp", "color_temp": 3000}`,
+		b.last("govee/"+deviceID+"/state"))
+	assert.Equal(t, mqttbridge.Online, b.last("govee/bridge/availability"))
+	assert.Equal(t, mqttbridge.Online, b.last("govee/"+deviceID+"/availability"))
+
+	var config map[string]any
+	require.NoError(t, json.Unmarshal([]byte(b.last("homeassistant/light/govee_1F80C5323236724E/config")), &config))
+	assert.Equal(t, "govee_1F80C5323236724E", config["unique_id"])
+	assert.Equal(t, "json", config["schema"])
+	assert.Equal(t, "govee/"+deviceID+"/state", config["state_topic"])
+	assert.Equal(t, "govee/"+deviceID+"/set", config["command_topic"])
+	assert.Equal(t, true, config["brightness"])
+	assert.InDelta(t, 100, config["brightness_scale"], 0)
+	assert.Equal(t, []any{"rgb", "color_temp"}, config["supported_color_modes"])
+	assert.Equal(t, true, config["color_temp_kelvin"])
+	assert.InDelta(t, 2000, config["min_kelvin"], 0)
+	assert.InDelta(t, 9000, config["max_kelvin"], 0)
+	assert.Equal(t, "all", config["availability_mode"])
+	assert.Equal(t, map[string]any{
+		"identifiers":  []any{deviceID},
+		"name":         "Govee H6008 " + deviceID,
+		"manufacturer": "Govee",
+		"model":        "H6008",
+		"sw_version":   "1.2.3",
+		"hw_version":   "1.0.10",
+	}, config["device"])
+
+	stop()
+	assert.Equal(t, mqttbridge.Offline, b.last("govee/bridge/availability"))
+}
+
+func TestBridgeSet(t *testing.T) {
+	b, sim, _ := startBridge(t)
+	require.Eventually(t, func() bool {
+		return b.last("govee/"+deviceID+"/state") != ""
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, b.Publish("govee/"+deviceID+"/set", []byte(`{"state": "ON", "color": {"r": 255, "g": 127, "b": 80}, "brightness": 30}`), false, 1))
+	require.Eventually(t, func() bool {
+		return b.last("govee/"+deviceID+"/state") == `{"state":"ON","brightness":30,"color_mode":"rgb","color":{"r":255,"g":127,"b":80}}`
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, govee.Color{R: 255, G: 127, B: 80}, sim.State().Color)
+
+	require.NoError(t, b.Publish("govee/"+deviceID+"/set", []byte(`{"state": "OFF"}`), false, 1))
+	require.Eventually(t, func() bool {
+		return sim.State().State == 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// Malformed commands and unknown devices are ignored.
+	require.NoError(t, b.Publish("govee/"+deviceID+"/set", []byte(`not json`), false, 1))
+	require.NoError(t, b.Publish("govee/AA:BB/set", []byte(`{"state": "ON"}`), false, 1))
+}
+
+func TestBridgeAvailability(t *testing.T) {
+	b, _, _ := startBridge(t, govee.WithActiveWindow(300*time.Millisecond))
+	topic := "govee/" + deviceID + "/availability"
+
+	require.Eventually(t, func() bool {
+		return b.last(topic) == mqttbridge.Offline
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{mqttbridge.Online, mqttbridge.Offline}, b.history(topic))
+
+	// A command refreshes the device's status, which brings it back.
+	require.NoError(t, b.Publish("govee/"+deviceID+"/set", []byte(`{"brightness": 90}`), false, 1))
+	require.Eventually(t, func() bool {
+		return b.last(topic) == mqttbridge.Online
+	}, 2*time.Second, 10*time.Millisecond)
+}
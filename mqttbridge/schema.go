@@ -0,0 +1,152 @@
+package mqttbridge
+
+import (
+	"cmp"
+
+	govee "github.com/swrm-io/go-vee"
+)
+
+// Color temperature range supported by Govee lights, in Kelvin.
+const (
+	minKelvin = 2000
+	maxKelvin = 9000
+)
+
+// Home Assistant color modes.
+const (
+	colorModeRGB       = "rgb"
+	colorModeColorTemp = "color_temp"
+)
+
+// lightState is a state payload in the Home Assistant JSON light schema.
+type lightState struct {
+	State      string       `json:"state"`
+	Brightness uint         `json:"brightness"`
+	ColorMode  string       `json:"color_mode"`
+	Color      *govee.Color `json:"color,omitempty"`
+	ColorTemp  uint         `json:"color_temp,omitempty"`
+}
+
+// newLightState returns the state payload for a device state. Govee
+// reports a color temperature of 0 while showing an RGB color.
+func newLightState(state govee.DeviceState) lightState {
+	s := lightState{
+		State:      "OFF",
+		Brightness: uint(state.Brightness),
+		ColorMode:  colorModeRGB,
+	}
+	if state.State == 1 {
+		s.State = "ON"
+	}
+	if state.ColorKelvin != 0 {
+		s.ColorMode = colorModeColorTemp
+		s.ColorTemp = uint(state.ColorKelvin)
+	} else {
+		s.Color = &state.Color
+	}
+	return s
+}
+
+// lightCommand is a command payload in the Home Assistant JSON light
+// schema. Fields Govee lights cannot act on, such as transition, are
+// ignored.
+type lightCommand struct {
+	State      string       `json:"state"`
+	Brightness *uint        `json:"brightness"`
+	Color      *govee.Color `json:"color"`
+	ColorTemp  *uint        `json:"color_temp"`
+}
+
+// delta returns the state delta a command asks for. Out of range values
+// are clamped.
+func (c lightCommand) delta() govee.StateDelta {
+	var delta govee.StateDelta
+	switch c.State {
+	case "ON":
+		on := govee.State(1)
+		delta.State = &on
+	case "OFF":
+		off := govee.State(0)
+		delta.State = &off
+	}
+	if c.Brightness != nil {
+		brightness := govee.NewBrightness(*c.Brightness)
+		delta.Brightness = &brightness
+	}
+	// Home Assistant sends one color mode at a time; prefer RGB should a
+	// command carry both.
+	if c.Color != nil {
+		color := govee.NewColor(c.Color.R, c.Color.G, c.Color.B)
+		delta.Color = &color
+	} else if c.ColorTemp != nil {
+		kelvin := govee.NewColorKelvin(*c.ColorTemp)
+		delta.ColorKelvin = &kelvin
+	}
+	return delta
+}
+
+// availability is one entry of a discovery config's availability list.
+type availability struct {
+	Topic string `json:"topic"`
+}
+
+// discoveryDevice describes the physical device in a discovery config.
+type discoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model,omitempty"`
+	SWVersion    string   `json:"sw_version,omitempty"`
+	HWVersion    string   `json:"hw_version,omitempty"`
+}
+
+// discoveryConfig is a Home Assistant MQTT discovery config for a light
+// using the JSON schema.
+type discoveryConfig struct {
+	// Name is null so the entity takes the device name.
+	Name                *string         `json:"name"`
+	UniqueID            string          `json:"unique_id"`
+	Schema              string          `json:"schema"`
+	StateTopic          string          `json:"state_topic"`
+	CommandTopic        string          `json:"command_topic"`
+	Availability        []availability  `json:"availability"`
+	AvailabilityMode    string          `json:"availability_mode"`
+	Brightness          bool            `json:"brightness"`
+	BrightnessScale     int             `json:"brightness_scale"`
+	SupportedColorModes []string        `json:"supported_color_modes"`
+	ColorTempKelvin     bool            `json:"color_temp_kelvin"`
+	MinKelvin           int             `json:"min_kelvin"`
+	MaxKelvin           int             `json:"max_kelvin"`
+	Device              discoveryDevice `json:"device"`
+}
+
+// discoveryConfig returns the discovery config for a device. The light is
+// available only while both the bridge and the device are.
+func (b *Bridge) discoveryConfig(state govee.DeviceState) discoveryConfig {
+	model := cmp.Or(state.SKU, "Light")
+	return discoveryConfig{
+		UniqueID:     uniqueID(state.DeviceID),
+		Schema:       "json",
+		StateTopic:   b.deviceTopic(state.DeviceID, "state"),
+		CommandTopic: b.deviceTopic(state.DeviceID, "set"),
+		Availability: []availability{
+			{Topic: bridgeAvailabilityTopic(b.prefix)},
+			{Topic: b.deviceTopic(state.DeviceID, "availability")},
+		},
+		AvailabilityMode:    "all",
+		Brightness:          true,
+		BrightnessScale:     100,
+		SupportedColorModes: []string{colorModeRGB, colorModeColorTemp},
+		ColorTempKelvin:     true,
+		MinKelvin:           minKelvin,
+		MaxKelvin:           maxKelvin,
+		Device: discoveryDevice{
+			Identifiers:  []string{state.DeviceID},
+			Name:         "Govee " + model + " " + state.DeviceID,
+			Manufacturer: "Govee",
+			Model:        state.SKU,
+			SWVersion:    state.WifiVersionSoft.Label(),
+			HWVersion:    state.WifiVersionHard.Label(),
+		},
+	}
+}
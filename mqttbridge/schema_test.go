@@ -0,0 +1,43 @@
+package mqttbridge
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+)
+
+func TestLightCommandDelta(t *testing.T) {
+	var cmd lightCommand
+	require.NoError(t, json.Unmarshal([]byte(`{"state": "OFF", "brightness": 250, "color_temp": 1500, "transition": 2}`), &cmd))
+	delta := cmd.delta()
+	require.NotNil(t, delta.State)
+	assert.Equal(t, govee.State(0), *delta.State)
+	require.NotNil(t, delta.Brightness)
+	assert.Equal(t, govee.Brightness(100), *delta.Brightness)
+	require.NotNil(t, delta.ColorKelvin)
+	assert.Equal(t, govee.ColorKelvin(2000), *delta.ColorKelvin)
+	assert.Nil(t, delta.Color)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"color": {"r": 300, "g": 1, "b": 2}, "color_temp": 3000}`), &cmd))
+	delta = cmd.delta()
+	require.NotNil(t, delta.Color)
+	assert.Equal(t, govee.Color{R: 255, G: 1, B: 2}, *delta.Color)
+	assert.Nil(t, delta.ColorKelvin)
+
+	assert.Equal(t, govee.StateDelta{}, lightCommand{}.delta())
+}
+
+func TestNewLightState(t *testing.T) {
+	assert.Equal(t, lightState{State: "ON", Brightness: 40, ColorMode: colorModeColorTemp, ColorTemp: 2700},
+		newLightState(govee.DeviceState{State: 1, Brightness: 40, ColorKelvin: 2700}))
+	assert.Equal(t, lightState{State: "OFF", Brightness: 10, ColorMode: colorModeRGB, Color: &govee.Color{R: 1, G: 2, B: 3}},
+		newLightState(govee.DeviceState{Brightness: 10, Color: govee.Color{R: 1, G: 2, B: 3}}))
+}
+
+func TestUniqueID(t *testing.T) {
+	assert.Equal(t, "govee_1F80C5323236724E", uniqueID("1F:80:C5:32:32:36:72:4E"))
+	assert.Equal(t, "govee_a-b_c", uniqueID("a-b_c/#+"))
+}
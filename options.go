@@ -0,0 +1,221 @@
+package govee
+
+import (
+	"net"
+	"time"
+)
+
+// Default network and timing settings used by NewController when no
+// options are supplied.
+const (
+	DefaultMulticastAddress = "239.255.255.250"
+	DefaultScanPort         = 4001
+	DefaultListenPort       = 4002
+	DefaultCommandPort      = 4003
+	DefaultScanInterval     = 60 * time.Second
+	DefaultActiveWindow     = 5 * time.Minute
+	DefaultReadBuffer       = 8192
+	DefaultFadeFrameRate    = 20
+	DefaultPollJitter       = time.Second
+	DefaultSweepRate        = 200
+	DefaultSweepWait        = 2 * time.Second
+)
+
+// config holds the tunable settings of a Controller.
+type config struct {
+	iface            *net.Interface
+	multicastAddress string
+	scanPort         int
+	listenPort       int
+	commandPort      int
+	scanInterval     time.Duration
+	activeWindow     time.Duration
+	readBuffer       int
+	transport        Transport
+	fadeFrameRate    int
+	sweepRate        int
+	sweepWait        time.Duration
+	evictAfter       int
+	pollInterval     time.Duration
+	pollJitter       time.Duration
+}
+
+// defaultConfig returns the settings matching the Govee LAN API defaults.
+func defaultConfig() config {
+	return config{
+		multicastAddress: DefaultMulticastAddress,
+		scanPort:         DefaultScanPort,
+		listenPort:       DefaultListenPort,
+		commandPort:      DefaultCommandPort,
+		scanInterval:     DefaultScanInterval,
+		activeWindow:     DefaultActiveWindow,
+		readBuffer:       DefaultReadBuffer,
+		fadeFrameRate:    DefaultFadeFrameRate,
+		pollJitter:       DefaultPollJitter,
+		sweepRate:        DefaultSweepRate,
+		sweepWait:        DefaultSweepWait,
+	}
+}
+
+// Option configures a Controller created by NewController.
+type Option func(*config)
+
+// WithInterface restricts multicast listening and scanning to the given
+// network interface. By default the system chooses the interface.
+func WithInterface(iface *net.Interface) Option {
+	return func(c *config) {
+		c.iface = iface
+	}
+}
+
+// WithMulticastAddress sets the address scan requests are sent to and
+// the group the controller listens on. A non-multicast address may be
+// used to scan a single host, which is mostly useful for testing.
+func WithMulticastAddress(addr string) Option {
+	return func(c *config) {
+		c.multicastAddress = addr
+	}
+}
+
+// WithPorts sets the UDP ports used for scan requests, for receiving
+// device responses and for sending commands to devices. The ports are
+// left unchanged unless all three are between 1 and 65535.
+func WithPorts(scan, listen, command int) Option {
+	return func(c *config) {
+		if validPort(scan) && validPort(listen) && validPort(command) {
+			c.scanPort = scan
+			c.listenPort = listen
+			c.commandPort = command
+		}
+	}
+}
+
+// validPort reports whether port is a usable UDP port number.
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+// WithScanInterval sets how often the controller scans for devices.
+func WithScanInterval(interval time.Duration) Option {
+	return func(c *config) {
+		if interval > 0 {
+			c.scanInterval = interval
+		}
+	}
+}
+
+// WithActiveWindow sets how recently a device must have been seen for
+// Device.Active to report true.
+func WithActiveWindow(window time.Duration) Option {
+	return func(c *config) {
+		if window > 0 {
+			c.activeWindow = window
+		}
+	}
+}
+
+// WithReadBuffer sets the size in bytes of the UDP read buffer.
+func WithReadBuffer(size int) Option {
+	return func(c *config) {
+		if size > 0 {
+			c.readBuffer = size
+		}
+	}
+}
+
+// WithFadeFrameRate sets how many intermediate commands per second
+// Device.FadeTo sends.
+func WithFadeFrameRate(fps int) Option {
+	return func(c *config) {
+		if fps > 0 {
+			c.fadeFrameRate = fps
+		}
+	}
+}
+
+// WithEviction removes devices that have not been seen for the given
+// number of scan intervals, stopping their handlers and emitting
+// DeviceRemoved. Devices added with Controller.AddDevice are never
+// evicted. Zero, the default, keeps devices forever.
+func WithEviction(scanCycles int) Option {
+	return func(c *config) {
+		if scanCycles >= 0 {
+			c.evictAfter = scanCycles
+		}
+	}
+}
+
+// WithPollInterval enables polling: the controller requests the status of
+// every active device once per interval, keeping the state reported by
+// Device fresh. The requests are spread out over the interval. Polling is
+// off by default; zero turns it back off.
+func WithPollInterval(interval time.Duration) Option {
+	return func(c *config) {
+		if interval >= 0 {
+			c.pollInterval = interval
+		}
+	}
+}
+
+// WithPollJitter sets the largest random delay added to each device's
+// status poll. It is capped at the device's share of the poll interval.
+// Zero polls devices at exactly evenly spaced times.
+func WithPollJitter(jitter time.Duration) Option {
+	return func(c *config) {
+		if jitter >= 0 {
+			c.pollJitter = jitter
+		}
+	}
+}
+
+// WithSweepRate sets how many hosts per second Controller.Sweep sends scan
+// requests to.
+func WithSweepRate(hostsPerSecond int) Option {
+	return func(c *config) {
+		if hostsPerSecond > 0 {
+			c.sweepRate = hostsPerSecond
+		}
+	}
+}
+
+// WithSweepWait sets how long Controller.Sweep waits for replies after
+// sending its last scan request.
+func WithSweepWait(wait time.Duration) Option {
+	return func(c *config) {
+		if wait > 0 {
+			c.sweepWait = wait
+		}
+	}
+}
+
+// WithTransport replaces the default UDP transport. The controller takes
+// ownership of the transport and closes it when it shuts down.
+func WithTransport(transport Transport) Option {
+	return func(c *config) {
+		c.transport = transport
+	}
+}
+
+// multicast reports whether the configured scan address is a multicast group.
+func (c config) multicast() bool {
+	ip := net.ParseIP(c.multicastAddress)
+	return ip != nil && ip.IsMulticast()
+}
+
+// localAddr returns the local address to send from, bound to the
+// configured interface if one was provided.
+func (c config) localAddr() *net.UDPAddr {
+	if c.iface == nil {
+		return nil
+	}
+	addrs, err := c.iface.Addrs()
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+			return &net.UDPAddr{IP: ipNet.IP}
+		}
+	}
+	return nil
+}
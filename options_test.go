@@ -0,0 +1,96 @@
+package govee
+
+import (
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewControllerDefaults(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+
+	assert.Equal(t, DefaultMulticastAddress, c.config.multicastAddress)
+	assert.Equal(t, DefaultScanPort, c.config.scanPort)
+	assert.Equal(t, DefaultListenPort, c.config.listenPort)
+	assert.Equal(t, DefaultCommandPort, c.config.commandPort)
+	assert.Equal(t, DefaultScanInterval, c.config.scanInterval)
+	assert.Equal(t, DefaultActiveWindow, c.config.activeWindow)
+	assert.Equal(t, DefaultReadBuffer, c.config.readBuffer)
+	assert.Equal(t, DefaultSweepRate, c.config.sweepRate)
+	assert.Equal(t, DefaultSweepWait, c.config.sweepWait)
+	assert.Zero(t, c.config.evictAfter)
+	assert.Zero(t, c.config.pollInterval)
+	assert.Equal(t, DefaultPollJitter, c.config.pollJitter)
+	assert.Nil(t, c.config.iface)
+	assert.True(t, c.config.multicast())
+	assert.Nil(t, c.config.localAddr())
+}
+
+func TestNewControllerOptions(t *testing.T) {
+	iface := &net.Interface{Index: 1, Name: "lo"}
+	c := NewController(slog.New(slog.DiscardHandler),
+		WithInterface(iface),
+		WithMulticastAddress("127.0.0.1"),
+		WithPorts(14001, 14002, 14003),
+		WithScanInterval(10*time.Second),
+		WithActiveWindow(time.Minute),
+		WithReadBuffer(4096),
+		WithSweepRate(50),
+		WithSweepWait(time.Second),
+		WithEviction(3),
+		WithPollInterval(time.Minute),
+		WithPollJitter(time.Millisecond),
+	)
+
+	assert.Equal(t, iface, c.config.iface)
+	assert.Equal(t, "127.0.0.1", c.config.multicastAddress)
+	assert.Equal(t, 14001, c.config.scanPort)
+	assert.Equal(t, 14002, c.config.listenPort)
+	assert.Equal(t, 14003, c.config.commandPort)
+	assert.Equal(t, 10*time.Second, c.config.scanInterval)
+	assert.Equal(t, time.Minute, c.config.activeWindow)
+	assert.Equal(t, 4096, c.config.readBuffer)
+	assert.Equal(t, 50, c.config.sweepRate)
+	assert.Equal(t, time.Second, c.config.sweepWait)
+	assert.Equal(t, 3, c.config.evictAfter)
+	assert.Equal(t, time.Minute, c.config.pollInterval)
+	assert.Equal(t, time.Millisecond, c.config.pollJitter)
+	assert.False(t, c.config.multicast())
+}
+
+func TestNewControllerIgnoresInvalidOptions(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler),
+		WithPorts(0, 5002, 5003),
+		WithPorts(5001, -1, 5003),
+		WithPorts(5001, 5002, 65536),
+		WithScanInterval(0),
+		WithActiveWindow(-time.Second),
+		WithReadBuffer(0),
+		WithSweepRate(-1),
+		WithSweepWait(0),
+		WithPollInterval(-time.Second),
+		WithPollJitter(-time.Second),
+	)
+
+	assert.Equal(t, DefaultScanPort, c.config.scanPort)
+	assert.Equal(t, DefaultListenPort, c.config.listenPort)
+	assert.Equal(t, DefaultCommandPort, c.config.commandPort)
+	assert.Equal(t, DefaultScanInterval, c.config.scanInterval)
+	assert.Equal(t, DefaultActiveWindow, c.config.activeWindow)
+	assert.Equal(t, DefaultReadBuffer, c.config.readBuffer)
+	assert.Equal(t, DefaultSweepRate, c.config.sweepRate)
+	assert.Equal(t, DefaultSweepWait, c.config.sweepWait)
+	assert.Zero(t, c.config.pollInterval)
+	assert.Equal(t, DefaultPollJitter, c.config.pollJitter)
+}
+
+func TestDeviceActiveWindow(t *testing.T) {
+	d := &Device{seen: time.Now().Add(-2 * time.Minute), activeWindow: time.Minute}
+	assert.False(t, d.Active())
+
+	d.activeWindow = 5 * time.Minute
+	assert.True(t, d.Active())
+}
@@ -0,0 +1,71 @@
+package govee
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// poll requests the status of every active device once per poll interval,
+// starting one interval from now, until ctx is done. Requests are spread
+// evenly over the interval so a large installation is not queried in a
+// single burst, and each one is delayed by a random jitter so devices
+// polled by several controllers drift apart. Responses are handled like
+// any other status update.
+func (c *Controller) poll(ctx context.Context) {
+	start := time.Now()
+	for {
+		start = start.Add(c.config.pollInterval)
+		if !sleepUntil(ctx, start) {
+			return
+		}
+
+		var devices []*Device
+		for _, device := range c.Devices() {
+			if device.Active() {
+				devices = append(devices, device)
+			}
+		}
+		for i, offset := range pollSchedule(len(devices), c.config.pollInterval, c.config.pollJitter) {
+			if !sleepUntil(ctx, start.Add(offset)) {
+				return
+			}
+			if err := devices[i].do(ctx, statusCommand()); err != nil {
+				devices[i].logger.Debug("Failed to poll device status", "error", err)
+			}
+		}
+	}
+}
+
+// pollSchedule returns when each of n devices should be polled, relative
+// to the start of a poll round. Device i is given the i-th of n equal
+// slots of interval, offset by a random delay of up to jitter that never
+// pushes it into the next slot.
+func pollSchedule(n int, interval, jitter time.Duration) []time.Duration {
+	if n == 0 {
+		return nil
+	}
+	slot := interval / time.Duration(n)
+	jitter = min(jitter, slot)
+	offsets := make([]time.Duration, n)
+	for i := range offsets {
+		offsets[i] = time.Duration(i) * slot
+		if jitter > 0 {
+			offsets[i] += rand.N(jitter)
+		}
+	}
+	return offsets
+}
+
+// sleepUntil waits until t and reports whether it did so before ctx was
+// done.
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
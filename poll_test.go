@@ -0,0 +1,73 @@
+package govee_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+)
+
+func TestPollSchedule(t *testing.T) {
+	assert.Empty(t, govee.PollSchedule(0, time.Minute, time.Second))
+
+	assert.Equal(t, []time.Duration{0, 15 * time.Second, 30 * time.Second, 45 * time.Second},
+		govee.PollSchedule(4, time.Minute, 0))
+
+	for range 100 {
+		offsets := govee.PollSchedule(4, time.Minute, time.Hour)
+		for i, offset := range offsets {
+			slot := time.Duration(i) * 15 * time.Second
+			assert.GreaterOrEqual(t, offset, slot)
+			assert.Less(t, offset, slot+15*time.Second, "jitter pushed device %d into the next slot", i)
+		}
+	}
+}
+
+func TestControllerPollsActiveDevices(t *testing.T) {
+	c, transport := startTransport(t, govee.WithPollInterval(20*time.Millisecond))
+	announce(t, c, transport, "10.0.0.5", "AA:BB", "H6159")
+
+	for range 2 {
+		p := nextCommand(t, transport, "devStatus")
+		assert.Equal(t, "10.0.0.5:4003", p.Addr)
+	}
+}
+
+func TestControllerSkipsInactivePolls(t *testing.T) {
+	c, transport := startTransport(t,
+		govee.WithActiveWindow(200*time.Millisecond),
+		govee.WithPollInterval(20*time.Millisecond),
+	)
+	stale := announce(t, c, transport, "10.0.0.5", "AA:BB", "H6159")
+	require.Eventually(t, func() bool { return !stale.Active() }, time.Second, time.Millisecond)
+	// Let a poll round that started while it was active finish.
+	time.Sleep(40 * time.Millisecond)
+	for len(transport.Sent()) > 0 {
+		<-transport.Sent()
+	}
+
+	announce(t, c, transport, "10.0.0.6", "CC:DD", "H6159")
+	for range 3 {
+		p := nextCommand(t, transport, "devStatus")
+		assert.Equal(t, "10.0.0.6:4003", p.Addr)
+	}
+}
+
+func TestControllerPollingOptIn(t *testing.T) {
+	tests := map[string][]govee.Option{
+		"default": nil,
+		"zero":    {govee.WithPollInterval(20 * time.Millisecond), govee.WithPollInterval(0)},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, transport := startTransport(t, opts...)
+			announce(t, c, transport, "10.0.0.5", "AA:BB", "H6159")
+
+			// Five polls would be due by now if polling were on.
+			time.Sleep(100 * time.Millisecond)
+			assert.Empty(t, transport.Sent())
+		})
+	}
+}
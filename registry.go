@@ -0,0 +1,322 @@
+package govee
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+)
+
+// DeviceOptions describes a device registered with AddDevice. Fields
+// that are not known may be left empty and are filled in when the device
+// answers a scan.
+type DeviceOptions struct {
+	// DeviceID is the device's unique identifier.
+	DeviceID string
+	// SKU is the device's model number.
+	SKU string
+}
+
+// pendingStatus is a status response from an address that no scan has
+// identified yet.
+type pendingStatus struct {
+	status   devStatusResponse
+	received time.Time
+}
+
+// Devices returns a slice of all managed devices. The slice is a copy and
+// may be retained by the caller.
+func (c *Controller) Devices() []*Device {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Clone(c.devices)
+}
+
+// DeviceByIP returns a pointer to a device by its IP address, or an error if not found.
+func (c *Controller) DeviceByIP(ip string) (*Device, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if device, ok := c.byIP[ip]; ok {
+		return device, nil
+	}
+	return nil, ErrNoDeviceFound
+}
+
+// DeviceByID returns a pointer to a device by its DeviceID, or an error if not found.
+func (c *Controller) DeviceByID(id string) (*Device, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if device, ok := c.byID[id]; ok {
+		return device, nil
+	}
+	return nil, ErrNoDeviceFound
+}
+
+// AddDevice registers a device at ip without waiting for it to answer a
+// multicast scan, for networks that block multicast. The controller
+// unicasts scan and status requests to the device as soon as it is
+// running and on every scan interval after that, and the device is never
+// removed by scanning. Adding an address that is already registered marks
+// the existing device as static and returns it, and adding a known
+// DeviceID at a new address moves the device there. It returns an error
+// wrapping ErrDeviceConflict if ip is registered to a device with a
+// different ID.
+func (c *Controller) AddDevice(ip string, opts DeviceOptions) (*Device, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil || addr.To4() == nil {
+		return nil, fmt.Errorf("invalid device address %q", ip)
+	}
+	ip = addr.String()
+
+	c.mu.Lock()
+	device, atIP := c.byID[opts.DeviceID], c.byIP[ip]
+	switch {
+	case atIP != nil && device != nil && atIP != device,
+		atIP != nil && device == nil && opts.DeviceID != "" && atIP.registeredID != "":
+		c.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s is registered to another device", ErrDeviceConflict, ip)
+	case device == nil && atIP != nil:
+		device = atIP
+	case device == nil:
+		device = c.register(ip)
+	}
+	if device.registeredIP != ip {
+		c.logger.Info("Device changed IP", "deviceID", opts.DeviceID, "old_ip", device.registeredIP, "new_ip", ip)
+		if c.byIP[device.registeredIP] == device {
+			delete(c.byIP, device.registeredIP)
+		}
+		device.registeredIP = ip
+		c.byIP[ip] = device
+	}
+	device.static = true
+	if opts.DeviceID != "" && device.registeredID == "" {
+		device.registeredID = opts.DeviceID
+		c.byID[opts.DeviceID] = device
+	}
+	c.mu.Unlock()
+
+	device.mu.Lock()
+	oldIP := device.ip
+	device.ip = ip
+	if device.deviceID == "" {
+		device.deviceID = opts.DeviceID
+	}
+	if device.sku == "" {
+		device.sku = opts.SKU
+	}
+	device.mu.Unlock()
+
+	if oldIP != ip {
+		c.publish(DeviceIPChanged{Device: device, OldIP: oldIP, NewIP: ip})
+	}
+	if opts.DeviceID != "" {
+		c.mu.Lock()
+		close(c.added)
+		c.added = make(chan struct{})
+		c.mu.Unlock()
+	}
+
+	// Probe the device right away if the controller is running; Start
+	// probes every static device itself. If the probe queue is full the
+	// device is probed on the next scan interval instead.
+	if c.running.Load() {
+		select {
+		case c.probes <- device:
+		default:
+		}
+	}
+	return device, nil
+}
+
+// staticDevices returns the devices registered with AddDevice.
+func (c *Controller) staticDevices() []*Device {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var static []*Device
+	for _, device := range c.devices {
+		if device.static {
+			static = append(static, device)
+		}
+	}
+	return static
+}
+
+// PendingIPs returns the addresses that sent status responses but have
+// not yet been identified by a scan, sorted.
+func (c *Controller) PendingIPs() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ips := make([]string, 0, len(c.pending))
+	for ip := range c.pending {
+		ips = append(ips, ip)
+	}
+	slices.Sort(ips)
+	return ips
+}
+
+// identify finds the device a scan response from srcAddr describes,
+// registering it if it is new and moving it to ip if it was known at a
+// different address. Devices are matched by ID first, then by IP for
+// devices whose ID is not yet known or, for static devices, was given
+// wrongly to AddDevice. It returns any status held for the
+// device while it was pending.
+func (c *Controller) identify(srcAddr, ip, id string) (*Device, *devStatusResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	device := c.byID[id]
+	if d, ok := c.byIP[ip]; ok && device == nil {
+		switch {
+		case d.registeredID == "":
+			device = d
+		case d.static:
+			// The ID given to AddDevice was wrong: trust the device
+			// answering at the registered address.
+			c.logger.Warn("Static device reported a different ID", "ip", ip, "registered_id", d.registeredID, "deviceID", id)
+			if c.byID[d.registeredID] == d {
+				delete(c.byID, d.registeredID)
+			}
+			d.registeredID = ""
+			device = d
+		default:
+			// Another device took over the address. The old one keeps
+			// its ID and moves when it is seen at its new address.
+			c.logger.Info("Device address taken over", "ip", ip, "old_deviceID", d.registeredID, "deviceID", id)
+		}
+	}
+
+	switch {
+	case device == nil:
+		device = c.register(ip)
+	case device.registeredIP != ip:
+		c.logger.Info("Device changed IP", "deviceID", id, "old_ip", device.registeredIP, "new_ip", ip)
+		if c.byIP[device.registeredIP] == device {
+			delete(c.byIP, device.registeredIP)
+		}
+		device.registeredIP = ip
+		c.byIP[ip] = device
+	}
+
+	if id != "" && device.registeredID == "" {
+		device.registeredID = id
+		c.byID[id] = device
+	}
+
+	var status *devStatusResponse
+	for _, addr := range []string{srcAddr, ip} {
+		if p, ok := c.pending[addr]; ok {
+			status = &p.status
+			delete(c.pending, addr)
+		}
+	}
+	return device, status
+}
+
+// register adds a new device at ip and starts its handler. c.mu must be
+// held.
+func (c *Controller) register(ip string) *Device {
+	// New device discovered, register it and start its handler.
+	c.logger.Debug("Discovered new device", "ip", ip)
+	ctx, cancel := context.WithCancel(c.ctx)
+	device := &Device{
+		ip:            ip,
+		registeredIP:  ip,
+		activeWindow:  c.config.activeWindow,
+		logger:        c.logger.With("device_ip", ip),
+		ctx:           ctx,
+		cancel:        cancel,
+		controller:    c,
+		response:      make(chan Message),
+		statusWaiters: map[chan DeviceState]struct{}{},
+	}
+	go device.handler()
+	c.devices = append(c.devices, device)
+	c.byIP[ip] = device
+	return device
+}
+
+// Forget removes a device from the controller, stopping its handler and
+// emitting DeviceRemoved. id is a device ID, or an IP address for devices
+// whose ID is not yet known. Returns ErrNoDeviceFound if there is no such
+// device. A forgotten device that answers a later scan is registered
+// again as a new device.
+func (c *Controller) Forget(id string) error {
+	c.mu.Lock()
+	device := c.byID[id]
+	if device == nil {
+		device = c.byIP[id]
+	}
+	if device == nil {
+		c.mu.Unlock()
+		return ErrNoDeviceFound
+	}
+	c.remove(device)
+	c.mu.Unlock()
+
+	c.removed(device)
+	return nil
+}
+
+// evict removes devices that have not been seen for the configured number
+// of scan intervals. Devices added with AddDevice and devices that have
+// never been seen are kept.
+func (c *Controller) evict() {
+	if c.config.evictAfter <= 0 {
+		return
+	}
+	limit := time.Duration(c.config.evictAfter) * c.config.scanInterval
+
+	var evicted []*Device
+	c.mu.Lock()
+	for _, device := range slices.Clone(c.devices) {
+		if device.static {
+			continue
+		}
+		device.mu.RLock()
+		seen := device.seen
+		device.mu.RUnlock()
+		if !seen.IsZero() && time.Since(seen) > limit {
+			c.remove(device)
+			evicted = append(evicted, device)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, device := range evicted {
+		device.logger.Info("Evicting device not seen for too long", "scan_cycles", c.config.evictAfter)
+		c.removed(device)
+	}
+}
+
+// remove deletes device from the registry. c.mu must be held.
+func (c *Controller) remove(device *Device) {
+	c.devices = slices.DeleteFunc(c.devices, func(d *Device) bool { return d == device })
+	if c.byIP[device.registeredIP] == device {
+		delete(c.byIP, device.registeredIP)
+	}
+	if c.byID[device.registeredID] == device {
+		delete(c.byID, device.registeredID)
+	}
+}
+
+// removed stops the handler of a device deleted from the registry and
+// announces its removal.
+func (c *Controller) removed(device *Device) {
+	device.cancel()
+	c.publish(DeviceRemoved{Device: device})
+}
+
+// hold keeps the latest status from an unidentified address until a scan
+// identifies it. Entries older than the active window are discarded.
+func (c *Controller) hold(ip string, status devStatusResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for addr, p := range c.pending {
+		if time.Since(p.received) > c.config.activeWindow {
+			delete(c.pending, addr)
+		}
+	}
+	c.logger.Debug("Holding status from unidentified device", "ip", ip)
+	c.pending[ip] = pendingStatus{status: status, received: time.Now()}
+}
@@ -0,0 +1,240 @@
+package govee_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+	"github.com/swrm-io/go-vee/goveetest"
+)
+
+func TestAddDevice(t *testing.T) {
+	ports, err := goveetest.FreePorts()
+	require.NoError(t, err)
+	sim, err := goveetest.NewDevice("127.0.0.2",
+		goveetest.WithPorts(ports),
+		goveetest.WithSKU("H6008"),
+		goveetest.WithState(govee.DeviceState{State: 1, Brightness: 70}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sim.Close() })
+
+	// Scans go to an address nothing answers on, as on a network that
+	// drops multicast.
+	c := goveetest.StartController(t,
+		govee.WithMulticastAddress("127.0.0.3"),
+		govee.WithPorts(ports.Scan, ports.Listen, ports.Command),
+	)
+
+	device, err := c.AddDevice(sim.IP(), govee.DeviceOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "", device.DeviceID())
+
+	require.Eventually(t, func() bool {
+		return device.SKU() == "H6008" && device.Brightness() == 70
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, sim.DeviceID(), device.DeviceID())
+	assert.Equal(t, govee.State(1), device.State())
+	assert.Contains(t, sim.Commands(), "scan")
+	assert.Contains(t, sim.Commands(), "devStatus")
+
+	byID, err := c.DeviceByID(sim.DeviceID())
+	require.NoError(t, err)
+	assert.Same(t, device, byID)
+	assert.Len(t, c.Devices(), 1)
+}
+
+func TestAddDeviceBeforeStart(t *testing.T) {
+	c := govee.NewController(slog.New(slog.DiscardHandler))
+
+	device, err := c.AddDevice("192.168.1.23", govee.DeviceOptions{DeviceID: "AA:BB", SKU: "H6159"})
+	require.NoError(t, err)
+	assert.Equal(t, "AA:BB", device.DeviceID())
+	assert.Equal(t, "H6159", device.SKU())
+	assert.Equal(t, "192.168.1.23", device.IP())
+
+	again, err := c.AddDevice("192.168.1.23", govee.DeviceOptions{})
+	require.NoError(t, err)
+	assert.Same(t, device, again)
+	byID, err := c.DeviceByID("AA:BB")
+	require.NoError(t, err)
+	assert.Same(t, device, byID)
+
+	_, err = c.AddDevice("lamp.local", govee.DeviceOptions{})
+	assert.Error(t, err)
+}
+
+func TestAddDeviceProbedOnceAtStart(t *testing.T) {
+	transport := goveetest.NewTransport()
+	c := govee.NewController(slog.New(slog.DiscardHandler), govee.WithTransport(transport))
+	_, err := c.AddDevice("10.0.0.5", govee.DeviceOptions{})
+	require.NoError(t, err)
+
+	go func() { _ = c.Start() }()
+	t.Cleanup(func() { _ = c.Shutdown() })
+	require.NoError(t, c.Ready(context.Background()))
+	var probes int
+	for _, p := range sentCommands(transport) {
+		if p.Addr == "10.0.0.5:4001" {
+			probes++
+		}
+	}
+	assert.Equal(t, 1, probes)
+}
+
+func TestAddDeviceKnownIDAtNewIP(t *testing.T) {
+	c := govee.NewController(slog.New(slog.DiscardHandler))
+	events := c.Subscribe(context.Background(), nil)
+	device, err := c.AddDevice("192.168.1.23", govee.DeviceOptions{DeviceID: "AA:BB"})
+	require.NoError(t, err)
+
+	moved, err := c.AddDevice("192.168.1.42", govee.DeviceOptions{DeviceID: "AA:BB"})
+	require.NoError(t, err)
+	assert.Same(t, device, moved)
+	assert.Equal(t, "192.168.1.42", device.IP())
+	byIP, err := c.DeviceByIP("192.168.1.42")
+	require.NoError(t, err)
+	assert.Same(t, device, byIP)
+	_, err = c.DeviceByIP("192.168.1.23")
+	assert.ErrorIs(t, err, govee.ErrNoDeviceFound)
+	assert.Equal(t, govee.DeviceIPChanged{Device: device, OldIP: "192.168.1.23", NewIP: "192.168.1.42"}, <-events)
+
+	// An address registered to another ID is not taken over.
+	_, err = c.AddDevice("192.168.1.50", govee.DeviceOptions{DeviceID: "CC:DD"})
+	require.NoError(t, err)
+	_, err = c.AddDevice("192.168.1.50", govee.DeviceOptions{DeviceID: "AA:BB"})
+	assert.ErrorIs(t, err, govee.ErrDeviceConflict)
+	_, err = c.AddDevice("192.168.1.50", govee.DeviceOptions{DeviceID: "EE:FF"})
+	assert.ErrorIs(t, err, govee.ErrDeviceConflict)
+	assert.Equal(t, "192.168.1.42", device.IP())
+	assert.Len(t, c.Devices(), 2)
+}
+
+func TestAddDeviceWrongID(t *testing.T) {
+	c, transport := startTransport(t)
+	device, err := c.AddDevice("10.0.0.5", govee.DeviceOptions{DeviceID: "AA:BB"})
+	require.NoError(t, err)
+
+	// The device at the address reports another ID, which replaces the
+	// one it was added with.
+	require.NoError(t, transport.Announce("10.0.0.5", "CC:DD", "H6159"))
+	require.Eventually(t, func() bool {
+		return device.DeviceID() == "CC:DD"
+	}, time.Second, time.Millisecond)
+	byID, err := c.DeviceByID("CC:DD")
+	require.NoError(t, err)
+	assert.Same(t, device, byID)
+	_, err = c.DeviceByID("AA:BB")
+	assert.ErrorIs(t, err, govee.ErrNoDeviceFound)
+	byIP, err := c.DeviceByIP("10.0.0.5")
+	require.NoError(t, err)
+	assert.Same(t, device, byIP)
+	assert.Len(t, c.Devices(), 1)
+}
+
+func TestAddressTakenOver(t *testing.T) {
+	c, transport := startTransport(t)
+	old := announce(t, c, transport, "10.0.0.5", "AA:BB", "H6159")
+
+	// A discovered device keeps its ID when another one answers at its
+	// address.
+	taken := announce(t, c, transport, "10.0.0.5", "CC:DD", "H6159")
+	assert.NotSame(t, old, taken)
+	byIP, err := c.DeviceByIP("10.0.0.5")
+	require.NoError(t, err)
+	assert.Same(t, taken, byIP)
+	byID, err := c.DeviceByID("AA:BB")
+	require.NoError(t, err)
+	assert.Same(t, old, byID)
+}
+
+func TestControllerConcurrentAccess(t *testing.T) {
+	const (
+		devices = 16
+		packets = 200
+		readers = 8
+	)
+	transport := goveetest.NewTransport()
+	c := goveetest.StartController(t, govee.WithTransport(transport))
+	go func() {
+		for range transport.Sent() {
+		}
+	}()
+
+	ip := func(i int) string { return fmt.Sprintf("10.0.0.%d", i+1) }
+	id := func(i int) string { return fmt.Sprintf("AA:BB:CC:DD:EE:FF:00:%02X", i) }
+
+	done := make(chan struct{})
+	var readersWG sync.WaitGroup
+	for range readers {
+		readersWG.Add(1)
+		go func() {
+			defer readersWG.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				for _, d := range c.Devices() {
+					_ = d.String()
+					_ = d.Active()
+					_, _, _, _ = d.State(), d.Brightness(), d.Color(), d.ColorKelvin()
+					_, _ = d.SKU(), d.WifiVersionSoft()
+				}
+				for i := range devices {
+					_, _ = c.DeviceByIP(ip(i))
+					_, _ = c.DeviceByID(id(i))
+				}
+				// Let the listener run on machines with few cores.
+				runtime.Gosched()
+			}
+		}()
+	}
+
+	// Deliver from many goroutines so the listener goroutine handles
+	// packets while the readers run.
+	var floodWG sync.WaitGroup
+	for i := range devices {
+		floodWG.Add(1)
+		go func() {
+			defer floodWG.Done()
+			for n := range packets {
+				if n%10 == 0 {
+					assert.NoError(t, transport.Announce(ip(i), id(i), "H6159"))
+				} else {
+					assert.NoError(t, transport.DeliverStatus(ip(i), govee.DeviceState{
+						State:      govee.State(n % 2),
+						Brightness: govee.Brightness(n % 101),
+					}))
+				}
+			}
+		}()
+	}
+	floodWG.Wait()
+	require.Eventually(t, func() bool {
+		var received uint64
+		for _, count := range c.Stats().PacketsReceived {
+			received += count
+		}
+		return received == devices*packets
+	}, 5*time.Second, time.Millisecond)
+	close(done)
+	readersWG.Wait()
+
+	require.Len(t, c.Devices(), devices)
+	for i := range devices {
+		byIP, err := c.DeviceByIP(ip(i))
+		require.NoError(t, err)
+		byID, err := c.DeviceByID(id(i))
+		require.NoError(t, err)
+		assert.Same(t, byIP, byID)
+	}
+}
@@ -0,0 +1,131 @@
+package govee_test
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+	"github.com/swrm-io/go-vee/goveetest"
+)
+
+func TestSweep(t *testing.T) {
+	c, transport := startTransport(t, govee.WithSweepRate(1000), govee.WithSweepWait(100*time.Millisecond))
+
+	// Answer the sweep as a device at 10.0.0.2 would, while a device
+	// outside the range answers too.
+	go func() {
+		<-transport.Sent()
+		_ = transport.Announce("10.0.0.2", "AA:BB", "H6159")
+		_ = transport.Announce("192.168.1.78", "CC:DD", "H6159")
+	}()
+
+	results, err := c.Sweep(context.Background(), "10.0.0.0/30")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "10.0.0.2", results[0].IP)
+	assert.Equal(t, "AA:BB", results[0].DeviceID)
+	assert.Equal(t, "H6159", results[0].SKU)
+	assert.Equal(t, govee.NewVersion(1, 2, 3), results[0].WifiVersionSoft)
+
+	device, err := c.DeviceByID("AA:BB")
+	require.NoError(t, err)
+	assert.Same(t, device, results[0].Device)
+
+	// The device outside the range is registered but not returned.
+	_, err = c.DeviceByID("CC:DD")
+	assert.NoError(t, err)
+}
+
+func TestSweepHighRate(t *testing.T) {
+	c, _ := startTransport(t, govee.WithSweepRate(math.MaxInt), govee.WithSweepWait(10*time.Millisecond))
+
+	_, err := c.Sweep(context.Background(), "127.0.0.0/30")
+	assert.NoError(t, err)
+}
+
+func TestSweepInvalidRange(t *testing.T) {
+	c, _ := startTransport(t)
+
+	for _, cidr := range []string{"192.168.1.1", "fe80::/64", "10.0.0.0/8"} {
+		_, err := c.Sweep(context.Background(), cidr)
+		assert.Error(t, err, cidr)
+	}
+	_, err := c.Sweep(context.Background(), "10.0.0.0/16", "10.1.0.0/24")
+	assert.Error(t, err)
+}
+
+func TestSweepNotStarted(t *testing.T) {
+	c := govee.NewController(slog.New(slog.DiscardHandler))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.Sweep(ctx, "127.0.0.0/30")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	c, _ = startTransport(t)
+	require.NoError(t, c.Shutdown())
+	_, err = c.Sweep(context.Background(), "127.0.0.0/30")
+	assert.ErrorIs(t, err, govee.ErrNotStarted)
+}
+
+func TestSweepContextExpired(t *testing.T) {
+	c, _ := startTransport(t, govee.WithSweepRate(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	results, err := c.Sweep(ctx, "127.0.0.0/29")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, results)
+}
+
+func TestScan(t *testing.T) {
+	c, transport := startTransport(t)
+	known := announce(t, c, transport, "192.168.1.23", "AA:BB", "H6159")
+	// The listener handles messages in order, so once the status is
+	// applied the scan response has reached every watcher.
+	require.NoError(t, transport.DeliverStatus("192.168.1.23", govee.DeviceState{Brightness: 10}))
+	require.Eventually(t, func() bool {
+		return known.Brightness() == 10
+	}, time.Second, time.Millisecond)
+
+	// Only devices answering this scan are returned.
+	go func() {
+		<-transport.Sent()
+		_ = transport.Announce("192.168.1.42", "CC:DD", "H6159")
+		_ = transport.Announce("192.168.1.42", "CC:DD", "H6159")
+	}()
+
+	devices, err := c.Scan(context.Background(), 200*time.Millisecond)
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	device, err := c.DeviceByID("CC:DD")
+	require.NoError(t, err)
+	assert.Same(t, device, devices[0])
+}
+
+func TestScanBeforeStart(t *testing.T) {
+	ports, err := goveetest.FreePorts()
+	require.NoError(t, err)
+	c := govee.NewController(slog.New(slog.DiscardHandler),
+		govee.WithMulticastAddress("127.0.0.1"),
+		govee.WithPorts(ports.Scan, ports.Listen, ports.Command),
+	)
+	result := make(chan error, 1)
+	go func() {
+		_, err := c.Scan(context.Background(), 10*time.Millisecond)
+		result <- err
+	}()
+
+	go func() { _ = c.Start() }()
+	defer c.Shutdown()
+	select {
+	case err := <-result:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("scan did not wait for the controller to start")
+	}
+}
@@ -0,0 +1,111 @@
+package govee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SceneDevice is the captured state of one device in a Scene.
+type SceneDevice struct {
+	DeviceID    string      `json:"deviceID"`
+	IP          string      `json:"ip"`
+	SKU         string      `json:"sku,omitempty"`
+	State       State       `json:"state"`
+	Brightness  Brightness  `json:"brightness"`
+	Color       Color       `json:"color"`
+	ColorKelvin ColorKelvin `json:"colorKelvin"`
+}
+
+// Scene is a snapshot of what every device looked like at a point in time.
+// Scenes marshal to and from JSON so they can be stored on disk and
+// replayed later with Controller.Apply.
+type Scene struct {
+	CapturedAt time.Time     `json:"capturedAt"`
+	Devices    []SceneDevice `json:"devices"`
+}
+
+// Snapshot refreshes the status of every device and captures it in a
+// Scene. Devices that fail to respond before ctx expires are captured with
+// their last known state and reported in the returned error, so the scene
+// is usable even when the error is not nil.
+func (c *Controller) Snapshot(ctx context.Context) (Scene, error) {
+	devices := c.Devices()
+	errs := make([]error, len(devices))
+	var wg sync.WaitGroup
+	for i, d := range devices {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := d.requestStatus(ctx); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", d, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	scene := Scene{CapturedAt: time.Now(), Devices: make([]SceneDevice, 0, len(devices))}
+	for _, d := range devices {
+		state := d.Snapshot()
+		scene.Devices = append(scene.Devices, SceneDevice{
+			DeviceID:    state.DeviceID,
+			IP:          state.IP,
+			SKU:         state.SKU,
+			State:       state.State,
+			Brightness:  state.Brightness,
+			Color:       state.Color,
+			ColorKelvin: state.ColorKelvin,
+		})
+	}
+	return scene, errors.Join(errs...)
+}
+
+// Apply restores every device in scene to its captured state, in parallel.
+// Devices are looked up by device ID, falling back to IP address. Devices
+// that were on get their color or color temperature and brightness before
+// being turned on so they do not flash; devices that were off are just
+// turned off. Failures are joined in the returned error.
+func (c *Controller) Apply(ctx context.Context, scene Scene) error {
+	errs := make([]error, len(scene.Devices))
+	var wg sync.WaitGroup
+	for i, captured := range scene.Devices {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.applySceneDevice(ctx, captured); err != nil {
+				errs[i] = fmt.Errorf("%s (%s): %w", captured.DeviceID, captured.IP, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// applySceneDevice restores a single device.
+func (c *Controller) applySceneDevice(ctx context.Context, captured SceneDevice) error {
+	d, err := c.DeviceByID(captured.DeviceID)
+	if err != nil {
+		d, err = c.DeviceByIP(captured.IP)
+		if err != nil {
+			return err
+		}
+	}
+
+	if captured.State == 0 {
+		return d.Do(ctx, TurnOffCommand())
+	}
+
+	commands := []Command{ColorCommand(captured.Color)}
+	if captured.ColorKelvin != 0 {
+		commands = []Command{ColorKelvinCommand(captured.ColorKelvin)}
+	}
+	commands = append(commands, BrightnessCommand(captured.Brightness), TurnOnCommand())
+	for _, cmd := range commands {
+		if err := d.Do(ctx, cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
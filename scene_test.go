@@ -0,0 +1,67 @@
+package govee_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	govee "github.com/swrm-io/go-vee"
+	"github.com/swrm-io/go-vee/goveetest"
+)
+
+func TestSnapshotAndApply(t *testing.T) {
+	movie := govee.DeviceState{State: 1, Brightness: 80, Color: govee.NewColor(255, 0, 0)}
+	c, device, sim := startSimulated(t, goveetest.WithState(movie))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	scene, err := c.Snapshot(ctx)
+	require.NoError(t, err)
+	require.Len(t, scene.Devices, 1)
+	assert.Equal(t, govee.SceneDevice{
+		DeviceID:   sim.DeviceID(),
+		IP:         sim.IP(),
+		SKU:        "H6159",
+		State:      1,
+		Brightness: 80,
+		Color:      govee.NewColor(255, 0, 0),
+	}, scene.Devices[0])
+
+	data, err := json.Marshal(scene)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"deviceID":"`+sim.DeviceID()+`"`)
+	var restored govee.Scene
+	require.NoError(t, json.Unmarshal(data, &restored))
+	assert.Equal(t, scene.Devices, restored.Devices)
+	assert.True(t, scene.CapturedAt.Equal(restored.CapturedAt))
+
+	require.NoError(t, device.Do(ctx, govee.ColorKelvinCommand(2700)))
+	require.NoError(t, device.Do(ctx, govee.TurnOffCommand()))
+	require.Eventually(t, func() bool { return sim.State().State == 0 }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, c.Apply(ctx, restored))
+	assert.Eventually(t, func() bool { return sim.State() == movie }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "turn", sim.Commands()[len(sim.Commands())-1], "power must be restored last")
+}
+
+func TestApplyUnknownDevice(t *testing.T) {
+	c, _, _ := startSimulated(t)
+
+	err := c.Apply(context.Background(), govee.Scene{Devices: []govee.SceneDevice{{DeviceID: "missing", IP: "10.9.9.9"}}})
+	assert.ErrorIs(t, err, govee.ErrNoDeviceFound)
+}
+
+func TestSnapshotUnresponsiveDevice(t *testing.T) {
+	c, _, sim := startSimulated(t, goveetest.WithState(govee.DeviceState{State: 1, Brightness: 5}))
+	require.NoError(t, sim.Close())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	scene, err := c.Snapshot(ctx)
+	assert.Error(t, err)
+	require.Len(t, scene.Devices, 1)
+	assert.Equal(t, sim.DeviceID(), scene.Devices[0].DeviceID)
+}
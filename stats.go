@@ -0,0 +1,68 @@
+package govee
+
+import (
+	"maps"
+	"sync"
+	"sync/atomic"
+)
+
+// Stats counts what the controller has received and failed to deliver
+// since it was created, for monitoring flaky devices and networks.
+type Stats struct {
+	// PacketsReceived counts the packets received for each known
+	// command, such as "scan" and "devStatus".
+	PacketsReceived map[string]uint64
+	// UnknownCommands counts packets with a command the controller does
+	// not handle.
+	UnknownCommands uint64
+	// ParseErrors counts packets that were not valid JSON or whose data
+	// did not match their command.
+	ParseErrors uint64
+	// DroppedCommands counts commands that failed with ErrQueueFull.
+	DroppedCommands uint64
+	// DroppedEvents counts events dropped for slow subscribers.
+	DroppedEvents uint64
+	// SendErrors counts network writes that failed.
+	SendErrors uint64
+}
+
+// stats holds the controller's counters.
+type stats struct {
+	// mu guards received.
+	mu       sync.Mutex
+	received map[string]uint64
+
+	unknownCommands atomic.Uint64
+	parseErrors     atomic.Uint64
+	droppedCommands atomic.Uint64
+	droppedEvents   atomic.Uint64
+	sendErrors      atomic.Uint64
+}
+
+// packetReceived counts a packet for a known command.
+func (s *stats) packetReceived(cmd string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.received == nil {
+		s.received = map[string]uint64{}
+	}
+	s.received[cmd]++
+}
+
+// Stats returns a snapshot of the controller's counters.
+func (c *Controller) Stats() Stats {
+	c.stats.mu.Lock()
+	received := maps.Clone(c.stats.received)
+	c.stats.mu.Unlock()
+	if received == nil {
+		received = map[string]uint64{}
+	}
+	return Stats{
+		PacketsReceived: received,
+		UnknownCommands: c.stats.unknownCommands.Load(),
+		ParseErrors:     c.stats.parseErrors.Load(),
+		DroppedCommands: c.stats.droppedCommands.Load(),
+		DroppedEvents:   c.stats.droppedEvents.Load(),
+		SendErrors:      c.stats.sendErrors.Load(),
+	}
+}
@@ -0,0 +1,52 @@
+package govee
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControllerStats(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	defer c.cancel()
+	assert.Equal(t, Stats{PacketsReceived: map[string]uint64{}}, c.Stats())
+
+	c.handleMessage("192.168.1.23", scanPacket("192.168.1.23", "AA:BB"))
+	c.handleMessage("192.168.1.23", statusPacket(1, 42))
+	c.handleMessage("192.168.1.23", statusPacket(1, 43))
+	c.handleMessage("192.168.1.23", parsePacket([]byte(`{"msg":{"cmd":"devStatus","data":"not a status"}}`)))
+	c.handleMessage("192.168.1.23", parsePacket([]byte(`{"msg":{"cmd":"reboot","data":{}}}`)))
+
+	c.running.Store(true)
+	for range commandQueueSize + 2 {
+		_ = c.trySend(Message{})
+	}
+
+	sub := c.Subscribe(context.Background(), nil)
+	for range eventBufferSize + 1 {
+		c.publish(DeviceRemoved{})
+	}
+	require.Len(t, sub, eventBufferSize)
+
+	stats := c.Stats()
+	assert.Equal(t, map[string]uint64{"scan": 1, "devStatus": 3}, stats.PacketsReceived)
+	assert.Equal(t, uint64(1), stats.UnknownCommands)
+	assert.Equal(t, uint64(1), stats.ParseErrors)
+	assert.Equal(t, uint64(2), stats.DroppedCommands)
+	assert.Equal(t, uint64(1), stats.DroppedEvents)
+	assert.Zero(t, stats.SendErrors)
+
+	// Snapshots do not share the counters.
+	stats.PacketsReceived["scan"] = 10
+	assert.Equal(t, uint64(1), c.Stats().PacketsReceived["scan"])
+}
+
+func TestControllerStatsSendErrors(t *testing.T) {
+	c := NewController(slog.New(slog.DiscardHandler))
+	err := c.write(Message{IP: "127.0.0.1", Payload: make(chan int)})
+	require.ErrorIs(t, err, ErrSendFailed)
+	assert.Equal(t, uint64(1), c.Stats().SendErrors)
+}
@@ -0,0 +1,176 @@
+package govee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrInvalidMessage is returned by Transport.Receive when a received packet
+// is not a valid API message. The Controller logs and skips such packets.
+var ErrInvalidMessage = errors.New("invalid API message")
+
+// Transport carries LAN API messages between a Controller and devices.
+// The default transport uses UDP multicast and unicast sockets; an
+// alternative can be supplied with WithTransport for testing, proxying or
+// recording traffic.
+type Transport interface {
+	// Send delivers msg to addr, a "host:port" string.
+	Send(addr string, msg *Wrapper) error
+
+	// Receive blocks until a message arrives or ctx is done, returning
+	// the IP address of the sender and the message. Packets that cannot
+	// be parsed are reported with an error wrapping ErrInvalidMessage.
+	Receive(ctx context.Context) (string, *Wrapper, error)
+
+	// Close releases the transport. Pending and later calls to Send and
+	// Receive fail with an error wrapping net.ErrClosed.
+	Close() error
+}
+
+// udpTransport is the default Transport. It receives on the configured
+// listen port, joining the multicast group when the scan address is one,
+// and sends every message from a single long-lived socket.
+type udpTransport struct {
+	config config
+	logger *slog.Logger
+	conn   *net.UDPConn
+	sender *net.UDPConn
+
+	// addrs caches resolved destinations, keyed by "ip:port", so each
+	// device address is only resolved once.
+	addrsMu sync.RWMutex
+	addrs   map[string]*net.UDPAddr
+}
+
+// newUDPTransport opens the socket device responses are received on and
+// the socket commands are sent from.
+func newUDPTransport(cfg config, logger *slog.Logger) (*udpTransport, error) {
+	conn, err := listen(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	// Don't defer conn.Close() here, close in Close
+
+	err = conn.SetReadBuffer(cfg.readBuffer)
+	if err != nil {
+		logger.Error("Failed to set UDP read buffer", "error", err)
+		conn.Close()
+		return nil, err
+	}
+
+	sender, err := net.ListenUDP("udp4", cfg.localAddr())
+	if err != nil {
+		logger.Error("Failed to open UDP sending socket", "error", err)
+		conn.Close()
+		return nil, err
+	}
+	return &udpTransport{
+		config: cfg,
+		logger: logger,
+		conn:   conn,
+		sender: sender,
+		addrs:  map[string]*net.UDPAddr{},
+	}, nil
+}
+
+// listen opens the socket device responses are received on. When the
+// configured scan address is a multicast group the socket joins it,
+// otherwise a plain unicast listener is used.
+func listen(cfg config, logger *slog.Logger) (*net.UDPConn, error) {
+	if !cfg.multicast() {
+		conn, err := net.ListenUDP("udp4", &net.UDPAddr{Port: cfg.listenPort})
+		if err != nil {
+			logger.Error("Failed to listen on UDP", "error", err)
+			return nil, err
+		}
+		return conn, nil
+	}
+
+	addr, err := net.ResolveUDPAddr("udp4", net.JoinHostPort(cfg.multicastAddress, strconv.Itoa(cfg.listenPort)))
+	if err != nil {
+		logger.Error("Failed to resolve UDP address", "error", err)
+		return nil, err
+	}
+
+	conn, err := net.ListenMulticastUDP("udp4", cfg.iface, addr)
+	if err != nil {
+		logger.Error("Failed to listen on multicast UDP", "error", err)
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Send marshals msg and writes it to addr from the sending socket.
+func (t *udpTransport) Send(addr string, msg *Wrapper) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	udpAddr, err := t.resolve(addr)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.sender.WriteToUDP(data, udpAddr)
+	return err
+}
+
+// resolve returns the UDP address for addr, resolving it on first use.
+func (t *udpTransport) resolve(addr string) (*net.UDPAddr, error) {
+	t.addrsMu.RLock()
+	udpAddr, ok := t.addrs[addr]
+	t.addrsMu.RUnlock()
+	if ok {
+		return udpAddr, nil
+	}
+
+	udpAddr, err := net.ResolveUDPAddr("udp4", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	t.addrsMu.Lock()
+	t.addrs[addr] = udpAddr
+	t.addrsMu.Unlock()
+	return udpAddr, nil
+}
+
+// Receive reads the next packet from the listening socket.
+func (t *udpTransport) Receive(ctx context.Context) (string, *Wrapper, error) {
+	buffer := make([]byte, t.config.readBuffer)
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", nil, err
+		}
+		// Set a short read deadline so we can check ctx.Done() regularly
+		_ = t.conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+		n, src, err := t.conn.ReadFromUDP(buffer)
+		if err != nil {
+			// If timeout, just continue to check ctx.Done()
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				continue
+			}
+			return "", nil, err
+		}
+
+		var request Wrapper
+		err = json.Unmarshal(buffer[:n], &request)
+		if err != nil {
+			return src.IP.String(), nil, fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+		}
+		return src.IP.String(), &request, nil
+	}
+}
+
+// Close closes the listening and sending sockets.
+func (t *udpTransport) Close() error {
+	return errors.Join(t.conn.Close(), t.sender.Close())
+}
@@ -0,0 +1,142 @@
+package govee
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestUDPTransport opens a UDP transport listening on a free loopback
+// port and closes it when the test finishes.
+func newTestUDPTransport(tb testing.TB) (*udpTransport, int) {
+	tb.Helper()
+	cfg := defaultConfig()
+	cfg.multicastAddress = "127.0.0.1"
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(tb, err)
+	cfg.listenPort = conn.LocalAddr().(*net.UDPAddr).Port
+	require.NoError(tb, conn.Close())
+
+	transport, err := newUDPTransport(cfg, slog.New(slog.DiscardHandler))
+	require.NoError(tb, err)
+	tb.Cleanup(func() { _ = transport.Close() })
+	return transport, cfg.listenPort
+}
+
+// newTestSink opens a loopback socket that discards everything it receives.
+func newTestSink(tb testing.TB) string {
+	tb.Helper()
+	sink, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(tb, err)
+	tb.Cleanup(func() { _ = sink.Close() })
+	go func() {
+		buffer := make([]byte, DefaultReadBuffer)
+		for {
+			if _, _, err := sink.ReadFromUDP(buffer); err != nil {
+				return
+			}
+		}
+	}()
+	return sink.LocalAddr().String()
+}
+
+func TestUDPTransportRoundTrip(t *testing.T) {
+	transport, port := newTestUDPTransport(t)
+
+	msg, err := newAPIRequest("devStatus", devStatusRequest{})
+	require.NoError(t, err)
+	require.NoError(t, transport.Send(net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), msg))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	src, received, err := transport.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", src)
+	assert.Equal(t, "devStatus", received.MSG.CMD)
+	assert.Len(t, transport.addrs, 1)
+}
+
+func TestUDPTransportInvalidMessage(t *testing.T) {
+	transport, port := newTestUDPTransport(t)
+
+	conn, err := net.Dial("udp4", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("not json"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, _, err = transport.Receive(ctx)
+	assert.ErrorIs(t, err, ErrInvalidMessage)
+}
+
+func TestUDPTransportReceiveCanceled(t *testing.T) {
+	transport, _ := newTestUDPTransport(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := transport.Receive(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestUDPTransportClosed(t *testing.T) {
+	transport, _ := newTestUDPTransport(t)
+	require.NoError(t, transport.Close())
+
+	msg, err := newAPIRequest("devStatus", devStatusRequest{})
+	require.NoError(t, err)
+	assert.ErrorIs(t, transport.Send("127.0.0.1:4003", msg), net.ErrClosed)
+}
+
+// BenchmarkUDPTransportSend measures sending colour updates through the
+// persistent socket of the default transport.
+func BenchmarkUDPTransportSend(b *testing.B) {
+	transport, _ := newTestUDPTransport(b)
+	addr := newTestSink(b)
+	msg, err := newAPIRequest("colorwc", colorRequest{Color: NewColor(255, 128, 0)})
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := transport.Send(addr, msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDialPerSend measures the previous approach of resolving and
+// dialing a new socket for every message, for comparison with
+// BenchmarkUDPTransportSend.
+func BenchmarkDialPerSend(b *testing.B) {
+	addr := newTestSink(b)
+	msg, err := newAPIRequest("colorwc", colorRequest{Color: NewColor(255, 128, 0)})
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			b.Fatal(err)
+		}
+		udpAddr, err := net.ResolveUDPAddr("udp4", addr)
+		if err != nil {
+			b.Fatal(err)
+		}
+		conn, err := net.DialUDP("udp4", nil, udpAddr)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := conn.Write(data); err != nil {
+			b.Fatal(err)
+		}
+		conn.Close()
+	}
+}
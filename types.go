@@ -4,13 +4,19 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"strconv"
+	"time"
 )
 
 // Message represents a message sent to or from a device.
 type Message struct {
 	IP      string
 	Payload any
+
+	// result, when set, receives the outcome of writing the message
+	// to the network.
+	result chan error
 }
 
 // Version represents a semantic version number used to identify
@@ -31,6 +37,15 @@ func (v Version) String() string {
 	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
 }
 
+// Label returns the string representation of the version, or "" for the
+// zero Version of a device that has not reported it.
+func (v Version) Label() string {
+	if v == (Version{}) {
+		return ""
+	}
+	return v.String()
+}
+
 // UnmarshalJSON parses the JSON-encoded version string.
 func (v *Version) UnmarshalJSON(b []byte) error {
 	val := bytes.Split(bytes.Trim(b, `"`), []byte{'.'})
@@ -147,3 +162,61 @@ func NewColorKelvin(value uint) ColorKelvin {
 func (c ColorKelvin) String() string {
 	return fmt.Sprintf("%dK", c)
 }
+
+// DeviceState is an immutable view of a device at a point in time: its
+// identity and firmware metadata, when it was last heard from, and its
+// controllable properties. Device.Snapshot returns one read atomically, so
+// the fields never mix values from different updates.
+//
+// Where a DeviceState describes a desired or simulated state, such as in
+// StateChanged comparisons or goveetest.WithState, only State, Brightness,
+// Color and ColorKelvin are used.
+type DeviceState struct {
+	IP              string    `json:"ip,omitempty"`
+	DeviceID        string    `json:"deviceID,omitempty"`
+	SKU             string    `json:"sku,omitempty"`
+	BleVersionHard  Version   `json:"bleVersionHard,omitzero"`
+	BleVersionSoft  Version   `json:"bleVersionSoft,omitzero"`
+	WifiVersionHard Version   `json:"wifiVersionHard,omitzero"`
+	WifiVersionSoft Version   `json:"wifiVersionSoft,omitzero"`
+	LastSeen        time.Time `json:"lastSeen,omitzero"`
+
+	State       State       `json:"state"`
+	Brightness  Brightness  `json:"brightness"`
+	Color       Color       `json:"color"`
+	ColorKelvin ColorKelvin `json:"colorKelvin"`
+}
+
+// Controls returns s with only the controllable properties set, for
+// comparing states regardless of metadata.
+func (s DeviceState) Controls() DeviceState {
+	return DeviceState{
+		State:       s.State,
+		Brightness:  s.Brightness,
+		Color:       s.Color,
+		ColorKelvin: s.ColorKelvin,
+	}
+}
+
+// LogValue implements slog.LogValuer, logging the state as a group.
+func (s DeviceState) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("ip", s.IP),
+		slog.String("deviceID", s.DeviceID),
+		slog.String("sku", s.SKU),
+		slog.String("bleVersionHard", s.BleVersionHard.String()),
+		slog.String("bleVersionSoft", s.BleVersionSoft.String()),
+		slog.String("wifiVersionHard", s.WifiVersionHard.String()),
+		slog.String("wifiVersionSoft", s.WifiVersionSoft.String()),
+	}
+	if !s.LastSeen.IsZero() {
+		attrs = append(attrs, slog.Time("lastSeen", s.LastSeen))
+	}
+	attrs = append(attrs,
+		slog.String("state", s.State.String()),
+		slog.Uint64("brightness", uint64(s.Brightness)),
+		slog.String("color", s.Color.String()),
+		slog.Uint64("colorKelvin", uint64(s.ColorKelvin)),
+	)
+	return slog.GroupValue(attrs...)
+}
@@ -2,9 +2,13 @@ package govee
 
 import (
 	"bytes"
+	"encoding/json"
+	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestVersionUnmarshalJSON(t *testing.T) {
@@ -88,6 +92,12 @@ func TestVersionMarshalJSON(t *testing.T) {
 	}
 }
 
+func TestVersionLabel(t *testing.T) {
+	assert.Equal(t, "1.0.10", NewVersion(1, 0, 10).Label())
+	assert.Equal(t, "0.0.1", NewVersion(0, 0, 1).Label())
+	assert.Equal(t, "", Version{}.Label())
+}
+
 func TestState(t *testing.T) {
 	// Test String method
 	tests := []struct {
@@ -125,3 +135,57 @@ func TestColor(t *testing.T) {
 		})
 	}
 }
+
+func TestDeviceStateJSON(t *testing.T) {
+	state := DeviceState{
+		IP:              "192.168.1.23",
+		DeviceID:        "AA:BB",
+		SKU:             "H6159",
+		BleVersionHard:  NewVersion(3, 1, 1),
+		BleVersionSoft:  NewVersion(1, 3, 1),
+		WifiVersionHard: NewVersion(1, 0, 10),
+		WifiVersionSoft: NewVersion(1, 2, 3),
+		LastSeen:        time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		State:           1,
+		Brightness:      42,
+		Color:           Color{R: 255},
+		ColorKelvin:     2700,
+	}
+	data, err := json.Marshal(state)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"ip": "192.168.1.23", "deviceID": "AA:BB", "sku": "H6159",
+		"bleVersionHard": "3.1.1", "bleVersionSoft": "1.3.1",
+		"wifiVersionHard": "1.0.10", "wifiVersionSoft": "1.2.3",
+		"lastSeen": "2024-05-01T12:00:00Z",
+		"state": 1, "brightness": 42, "color": {"r": 255, "g": 0, "b": 0}, "colorKelvin": 2700
+	}`, string(data))
+
+	var decoded DeviceState
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, state, decoded)
+
+	// Metadata is omitted for bare states.
+	data, err = json.Marshal(state.Controls())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"state": 1, "brightness": 42, "color": {"r": 255, "g": 0, "b": 0}, "colorKelvin": 2700}`, string(data))
+}
+
+func TestDeviceStateLogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.Info("snapshot", "device", DeviceState{IP: "192.168.1.23", DeviceID: "AA:BB", State: 1, Brightness: 42})
+
+	assert.Contains(t, buf.String(), "device.ip=192.168.1.23")
+	assert.Contains(t, buf.String(), "device.deviceID=AA:BB")
+	assert.Contains(t, buf.String(), "device.state=On")
+	assert.Contains(t, buf.String(), "device.brightness=42")
+	assert.NotContains(t, buf.String(), "lastSeen")
+}